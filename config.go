@@ -1,14 +1,15 @@
 package olayc
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
-	yaml "gopkg.in/yaml.v2"
 )
 
 const (
@@ -22,6 +23,11 @@ type KV struct {
 	value any
 }
 
+// NewKV returns a KV of key and value, e.g. for `LoadKVs()` and `KVsSource()`.
+func NewKV(key string, value any) KV {
+	return KV{key, value}
+}
+
 // loadOptionFunc is function handling `*loadOptions`
 type loadOptionFunc func(*loadOptions)
 
@@ -29,6 +35,21 @@ type loadOptionFunc func(*loadOptions)
 type loadOptions struct {
 	filesRequired []string
 	usageEntries  []usageEntry
+	sources       []Source
+	priorities    []namedPriority
+	arrayMerge    MergeStrategy
+	arrayMergeAt  map[string]MergeStrategy
+	keyNorm       KeyNormalization
+	envPrefix     string
+	envSeparator  string
+	envBindings   []envBinding
+	resolvers     map[string]Resolver
+}
+
+// namedPriority is the priority of sources matching name.
+type namedPriority struct {
+	name     string
+	priority int
 }
 
 // usageEntry is an entry for usage message.
@@ -46,6 +67,87 @@ func WithFileRequire(name string) loadOptionFunc {
 	}
 }
 
+// WithDefaultsFS returns a loadOptionFunc appends a defaults file from fs.FS, e.g. embed.FS.
+// The defaults are overlayed as the bottom layer, beneath the files provided by commandline.
+func WithDefaultsFS(fsys fs.FS, path string) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.sources = append(opt.sources, &fsSource{fsys, path, PriorityDefaults, nil, nil})
+	}
+}
+
+// WithSource returns a loadOptionFunc appends a source, it's overlayed with the built-in sources according to its priority.
+func WithSource(src Source) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.sources = append(opt.sources, src)
+	}
+}
+
+// WithPriority returns a loadOptionFunc overrides the priority of sources matching name.
+// The name matches the built-in sources 'args' and 'envs', or the file name, e.g. 'ops.yaml' matches '/etc/app/ops.yaml'.
+// For example, `WithPriority("ops.yaml", PriorityEnv+1)` makes the file overlayed above environments.
+// If a source matches multiple names, the last one wins.
+func WithPriority(name string, priority int) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.priorities = append(opt.priorities, namedPriority{name, priority})
+	}
+}
+
+// WithArrayMerge returns a loadOptionFunc sets the default strategy of merging arrays among sources, refer to `OlayConfig.SetArrayMerge()`.
+func WithArrayMerge(strategy MergeStrategy) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.arrayMerge = strategy
+	}
+}
+
+// WithArrayMergeAt returns a loadOptionFunc sets the strategy of merging arrays of the key path, refer to `OlayConfig.SetArrayMergeAt()`.
+func WithArrayMergeAt(key string, strategy MergeStrategy) loadOptionFunc {
+	return func(opt *loadOptions) {
+		if opt.arrayMergeAt == nil {
+			opt.arrayMergeAt = make(map[string]MergeStrategy)
+		}
+		opt.arrayMergeAt[key] = strategy
+	}
+}
+
+// WithKeyNormalization returns a loadOptionFunc sets the policy of matching keys, refer to `OlayConfig.SetKeyNormalization()`.
+func WithKeyNormalization(n KeyNormalization) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.keyNorm = n
+	}
+}
+
+// WithEnvPrefix returns a loadOptionFunc sets the required prefix of environments, e.g. 'MYAPP_', the prefix is stripped.
+// Environments are loaded if the prefix is set, refer to `LoadPrefixedEnvs()`.
+func WithEnvPrefix(prefix string) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.envPrefix = prefix
+	}
+}
+
+// WithEnvSeparator returns a loadOptionFunc sets the nesting separator of environments, e.g. '__', the default is '_'.
+func WithEnvSeparator(separator string) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.envSeparator = separator
+	}
+}
+
+// WithEnvBinding returns a loadOptionFunc binds the key to the environment variables, refer to `OlayConfig.BindEnv()`.
+func WithEnvBinding(key string, envNames ...string) loadOptionFunc {
+	return func(opt *loadOptions) {
+		opt.envBindings = append(opt.envBindings, envBinding{key, envNames})
+	}
+}
+
+// WithResolver returns a loadOptionFunc registers the resolver of scheme, refer to `OlayConfig.RegisterResolver()`.
+func WithResolver(scheme string, r Resolver) loadOptionFunc {
+	return func(opt *loadOptions) {
+		if opt.resolvers == nil {
+			opt.resolvers = make(map[string]Resolver)
+		}
+		opt.resolvers[scheme] = r
+	}
+}
+
 // WithUsage appends a usage message, when there are parsing errors or '-h|--help' arguments, usage message will be printed.
 // If there is no defaultValue, set it to nil.
 func WithUsage(key string, knd reflect.Kind, defaultValue any, help string) loadOptionFunc {
@@ -55,9 +157,10 @@ func WithUsage(key string, knd reflect.Kind, defaultValue any, help string) load
 }
 
 // Print application usage message.
-func usageApp(entries []usageEntry) {
+func usageApp(entries []usageEntry, bindings []envBinding) {
 	if len(entries) == 0 {
 		fmt.Println("No usage info.")
+		usageEnvBindings(bindings)
 		return
 	}
 	fmt.Println("Usage of app:")
@@ -69,90 +172,270 @@ func usageApp(entries []usageEntry) {
 		if entry.defaultValue != nil {
 			fmt.Printf(" (default %v)", entry.defaultValue)
 		}
+		for _, b := range bindings {
+			if b.key == entry.key {
+				fmt.Printf(" (env %v)", strings.Join(b.names, ", "))
+			}
+		}
 		fmt.Println("")
 	}
+	usageEnvBindings(bindings)
 }
 
 // OlayConfig is composition of multiple configure sources, each source is overlayed from bottom to top.
 // The top layer is visible if there is key conflicted among layers.
 // The configure sources can be configure files, environments and commandline arguments.
 type OlayConfig struct {
-	merged map[any]any
+	merged  map[any]any
+	sources []Source
+	origins map[string]Origin
+	layers  []*Layer
+	sealed  map[string]bool
+
+	arrayMerge   MergeStrategy
+	arrayMergeAt map[string]MergeStrategy
+	arrays       map[string][]mergeArray
+	keyNorm      KeyNormalization
+	envBindings  []envBinding
+	resolvers    map[string]Resolver
+
+	// mu guards the resolved configure, it's nil if it's stale, refer to `resolvedTree()`.
+	mu         sync.Mutex
+	resolved   map[any]any
+	resolveErr error
 }
 
 // New allocates and returns a new OlayConfig.
 func New() *OlayConfig {
 	return &OlayConfig{
-		merged: make(map[any]any),
+		merged:       make(map[any]any),
+		origins:      make(map[string]Origin),
+		sealed:       make(map[string]bool),
+		arrayMergeAt: make(map[string]MergeStrategy),
+		arrays:       make(map[string][]mergeArray),
+		resolvers:    make(map[string]Resolver),
+	}
+}
+
+// AddSource registers a source, registered sources are loaded by `LoadSources()`.
+func (c *OlayConfig) AddSource(src Source) {
+	c.sources = append(c.sources, src)
+}
+
+// LoadSources loads all registered sources ordered by priority, the source with higher priority is overlayed above the lower ones.
+// Sources with the same priority are overlayed in the order they are registered, the previously registered one wins.
+// The placeholders are resolved after all sources are loaded, refer to `Resolve()`.
+func (c *OlayConfig) LoadSources() error {
+	srcs := make([]Source, len(c.sources))
+	copy(srcs, c.sources)
+	sortSources(srcs)
+	for _, src := range srcs {
+		err := c.LoadSource(src)
+		if err != nil {
+			return err
+		}
+	}
+	return c.Resolve()
+}
+
+// LoadSource loads a source immediately, it's overlayed beneath the previously loaded ones.
+func (c *OlayConfig) LoadSource(src Source) error {
+	err := c.load(src)
+	if err != nil {
+		return errors.Wrapf(err, "Load source %v error", src.Name())
 	}
+	return nil
 }
 
 // Load yaml config from file.
+// The include directives are resolved, refer to `IncludeKey`.
 func (c *OlayConfig) LoadYamlFile(filepath string) error {
-	data, err := os.ReadFile(filepath)
+	err := c.load(YamlFileSource(filepath))
 	if err != nil {
 		return errors.Wrap(err, "LoadYamlFile error")
 	}
-	return c.LoadYaml(data)
+	return nil
 }
 
 // Load yaml from bytes.
 func (c *OlayConfig) LoadYaml(data []byte) error {
-	var m = make(map[any]any)
-	err := yaml.Unmarshal(data, &m)
+	err := c.load(YamlSource(data))
 	if err != nil {
 		return errors.Wrap(err, "LoadYaml error")
 	}
-	copyMap(c.merged, m)
 	return nil
 }
 
 // Load json config from file.
+// The include directives are resolved, refer to `IncludeKey`.
 func (c *OlayConfig) LoadJsonFile(filepath string) error {
-	data, err := os.ReadFile(filepath)
+	err := c.load(JsonFileSource(filepath))
 	if err != nil {
 		return errors.Wrap(err, "LoadJsonFile error")
 	}
-	return c.LoadJson(data)
+	return nil
 }
 
 // Load json from bytes.
-// To unmarshal a JSON object into a map using the standard library "encoding/json",
-// the map's key type must either be any string type, an integer.
-// Thus, the unmarshal map type is map[string]any(and all sub-maps), it not compatible with `copyMap()` which is accepting type map[any]any.
-// We must convert `map[string]any` to `map[any]any`, this is simplily done by marshal/unmarshal with "gopkg.in/yaml.v2".
 func (c *OlayConfig) LoadJson(data []byte) error {
-	var m = make(map[string]any)
-	err := json.Unmarshal(data, &m)
+	err := c.load(JsonSource(data))
 	if err != nil {
 		return errors.Wrap(err, "LoadJson error")
 	}
+	return nil
+}
 
-	var m1 map[any]any
-	m1, err = convertMap(m)
+// Load toml config from file.
+// The include directives are resolved, refer to `IncludeKey`.
+func (c *OlayConfig) LoadTomlFile(filepath string) error {
+	err := c.load(TomlFileSource(filepath))
 	if err != nil {
-		return errors.Wrap(err, "LoadJson error")
+		return errors.Wrap(err, "LoadTomlFile error")
 	}
+	return nil
+}
 
-	copyMap(c.merged, m1)
+// Load toml from bytes.
+// Tables and arrays of tables are converted to sub-trees and arrays,
+// offset datetimes are time.Time values, local datetimes/dates/times are string values.
+func (c *OlayConfig) LoadToml(data []byte) error {
+	err := c.load(TomlSource(data))
+	if err != nil {
+		return errors.Wrap(err, "LoadToml error")
+	}
 	return nil
 }
 
+// Load ini config from file.
+func (c *OlayConfig) LoadIniFile(filepath string) error {
+	err := c.load(IniFileSource(filepath))
+	if err != nil {
+		return errors.Wrap(err, "LoadIniFile error")
+	}
+	return nil
+}
+
+// Load ini from bytes.
+// The section header '[foo.redis]' and key 'host' are converted to 'foo.redis.host'.
+// Values are interpreted as same as commandline arguments, refer to `interpret(string)`, quoted values are kept as string.
+func (c *OlayConfig) LoadIni(data []byte) error {
+	err := c.load(IniSource(data))
+	if err != nil {
+		return errors.Wrap(err, "LoadIni error")
+	}
+	return nil
+}
+
+// Load java properties config from file.
+func (c *OlayConfig) LoadPropertiesFile(filepath string) error {
+	err := c.load(PropertiesFileSource(filepath))
+	if err != nil {
+		return errors.Wrap(err, "LoadPropertiesFile error")
+	}
+	return nil
+}
+
+// Load java properties from bytes.
+// The dotted keys, e.g. 'foo.redis.host', are converted to sub-trees.
+// Values are interpreted as same as commandline arguments, refer to `interpret(string)`.
+func (c *OlayConfig) LoadProperties(data []byte) error {
+	err := c.load(PropertiesSource(data))
+	if err != nil {
+		return errors.Wrap(err, "LoadProperties error")
+	}
+	return nil
+}
+
+// Load config from io.Reader with the format,
+// which is one of "yaml", "json", "toml", "ini", "properties" and "dotenv".
+// E.g. `LoadReader(os.Stdin, "yaml")`.
+func (c *OlayConfig) LoadReader(r io.Reader, format string) error {
+	err := c.load(ReaderSource(r, format))
+	if err != nil {
+		return errors.Wrap(err, "LoadReader error")
+	}
+	return nil
+}
+
+// Load config file from fs.FS, e.g. embed.FS.
+// The format is detected by the file extension:
+// .yaml/.yml, .json, .toml, .ini, .properties and .env.
+// The include directives are resolved in fsys, refer to `IncludeKey`.
+func (c *OlayConfig) LoadFS(fsys fs.FS, path string) error {
+	err := c.load(FSSource(fsys, path))
+	if err != nil {
+		return errors.Wrap(err, "LoadFS error")
+	}
+	return nil
+}
+
+// Load config files in the directory, e.g. '/etc/app/conf.d'.
+// Only files with known extensions are loaded, refer to `LoadFS()`. Sub-directories and hidden files are skipped.
+// The files are ordered lexically, the latter file wins, e.g. '90-local.yaml' is overlayed above '10-base.yaml'.
+func (c *OlayConfig) LoadDir(dir string) error {
+	srcs, err := DirSources(dir)
+	if err != nil {
+		return errors.Wrap(err, "LoadDir error")
+	}
+	for _, src := range srcs {
+		err = c.LoadSource(src)
+		if err != nil {
+			return errors.Wrap(err, "LoadDir error")
+		}
+	}
+	return nil
+}
+
+// Load from key-per-file directory, e.g. the kubernetes ConfigMaps and Secrets mounted as volumes. Return numbers of kvs loaded.
+// The file names are dotted keys, and the file contents are values, e.g.
+// file '/etc/config/foo.redis.host' with content 'localhost' is converted to 'foo.redis.host=localhost'.
+// The kubelet entries prefixed with '..', e.g. '..data', are skipped.
+//
+// If there are overlap keys, refer to 'LoadKVs()'.
+func (c *OlayConfig) LoadKeyDir(dir string) (int, error) {
+	src := &keyDirSource{dir, PriorityFile}
+	kvs, err := src.kvs()
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadKeyDir error")
+	}
+	err = c.merge(kvsToMap(kvs), sourceOrigin(src), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadKeyDir error")
+	}
+	return len(kvs), nil
+}
+
+// Load all keys under the prefix from consul KV. Return numbers of kvs loaded.
+// The addr is the consul HTTP address, e.g. 'http://127.0.0.1:8500', the token is the ACL token which can be empty.
+// The keys are converted by trimming the prefix and replacing '/' with '.',
+// e.g. with prefix 'app/', key 'app/foo/redis/host' is converted to 'foo.redis.host'.
+// Values are interpreted as same as commandline arguments, refer to `interpret(string)`.
+//
+// If there are overlap keys, refer to 'LoadKVs()'.
+func (c *OlayConfig) LoadConsul(addr string, prefix string, token string) (int, error) {
+	src := ConsulSource(addr, prefix, token)
+	kvs, err := src.kvs()
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadConsul error")
+	}
+	err = c.merge(kvsToMap(kvs), sourceOrigin(src), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadConsul error")
+	}
+	return len(kvs), nil
+}
+
 // Load from arguments. Return numbers of kvs loaded.
 // The internal olayc flags which prefix with `-oc.|--oc.` are ignored.
 //
 // If there are overlap keys, refer to 'LoadKVs()'.
 func (c *OlayConfig) LoadArgs(args []string) (int, error) {
-	var kvs []KV
-	psr := &flagParser{}
-	psr.parse(args)
-	for _, kv := range psr.kvs {
-		if strings.HasPrefix(kv.key, internalFlagPrefix) {
-			continue
-		}
-		kvs = append(kvs, kv)
+	src := newArgsSource(args)
+	err := c.merge(kvsToMap(src.kvs), sourceOrigin(src), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadArgs error")
 	}
-	return c.LoadKVs(kvs)
+	return len(src.kvs), nil
 }
 
 // Load from environments. Return numbers of kvs loaded.
@@ -163,9 +446,65 @@ func (c *OlayConfig) LoadArgs(args []string) (int, error) {
 //
 // If there are overlap envs, e.g. 'TERM=tmux' 'TERM_PROGRAM=tmux', refer to 'LoadKVs()'.
 func (c *OlayConfig) LoadEnvs(envs []string) (int, error) {
-	psr := &envParser{}
-	psr.parse(envs)
-	return c.LoadKVs(psr.kvs)
+	src := newEnvsSource(envs)
+	err := c.merge(kvsToMap(src.kvs), sourceOrigin(src), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadEnvs error")
+	}
+	return len(src.kvs), nil
+}
+
+// Load from environments with the prefix and the nesting separator. Return numbers of kvs loaded.
+//
+// Only the names with the prefix are loaded, and the prefix is stripped, e.g. the prefix 'MYAPP_' loads 'MYAPP_FOO_NAME' as 'foo.name'.
+// The separator is replaced by '.', e.g. the separator '__' loads 'FOO__MAX_CONNS' as 'foo.max_conns'.
+// The empty prefix loads all names, and the empty separator is '_'.
+func (c *OlayConfig) LoadPrefixedEnvs(envs []string, prefix string, separator string) (int, error) {
+	src := newPrefixedEnvsSource(envs, prefix, separator)
+	err := c.merge(kvsToMap(src.kvs), sourceOrigin(src), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadPrefixedEnvs error")
+	}
+	return len(src.kvs), nil
+}
+
+// Load from dotenv(.env) file. Return numbers of kvs loaded.
+func (c *OlayConfig) LoadDotenvFile(filepath string) (int, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadDotenvFile error")
+	}
+	n, err := c.loadDotenv(data, sourceOrigin(DotenvFileSource(filepath)))
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadDotenvFile error")
+	}
+	return n, nil
+}
+
+// Load from dotenv bytes. Return numbers of kvs loaded.
+// Variables like '${VAR}' are expanded with the previously defined keys in the file, then the process environments.
+// The keys are converted as same as `LoadEnvs()`, e.g. 'FOO_NAME=foo' is converted to 'foo.name=foo'.
+func (c *OlayConfig) LoadDotenv(data []byte) (int, error) {
+	n, err := c.loadDotenv(data, sourceOrigin(DotenvSource(data)))
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadDotenv error")
+	}
+	return n, nil
+}
+
+// Load from dotenv bytes with the origin. Return numbers of kvs loaded.
+func (c *OlayConfig) loadDotenv(data []byte, origin Origin) (int, error) {
+	psr := &dotenvParser{lookup: os.LookupEnv}
+	err := psr.parse(data)
+	if err != nil {
+		return 0, err
+	}
+	src := newEnvsSource(psr.literalEnvs())
+	err = c.merge(kvsToMap(src.kvs), origin, psr.lines)
+	if err != nil {
+		return 0, err
+	}
+	return len(src.kvs), nil
 }
 
 // Load from key-value pairs. Return number of kvs loaded.
@@ -176,58 +515,68 @@ func (c *OlayConfig) LoadEnvs(envs []string) (int, error) {
 // For example, if 'foo.redis' is loaded previously, the return value is 'redis.cluster',
 // or if the 'foo.redis.host' is loaded previously, the return value is '{"host": "redis.cluster"}'.
 func (c *OlayConfig) LoadKVs(kvs []KV) (int, error) {
-	var m = make(map[any]any)
-	for _, kv := range kvs {
-		var cur any = m
-		sps := strings.Split(kv.key, ".")
-		for j, sp := range sps {
-			var curM map[any]any
-			var ok bool
-			// Current node is scalar value
-			if curM, ok = cur.(map[any]any); !ok {
-				break
-			}
-
-			// Add subtree or value if empty
-			if _, ok = curM[sp]; !ok {
-				if j == len(sps)-1 {
-					curM[sp] = kv.value
-				} else {
-					curM[sp] = make(map[any]any)
-				}
-			}
-			cur = curM[sp]
-		}
+	err := c.merge(kvsToMap(kvs), Origin{Kind: "kvs"}, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadKVs error")
 	}
-	copyMap(c.merged, m)
 	return len(kvs), nil
 }
 
 // Get value with the given key, return nil if doesn't exist.
 // The key is splitted by seperator '.', e.g. 'foo.name'.
+// Array items are indexed by '[n]', e.g. 'foo.servers[0].host',
+// and map keys containing '.' are quoted, e.g. 'labels."app.kubernetes.io/name"', refer to `parseKeyPath()`.
 // The key is case sensitive, thus, 'foo.Name' is different from 'foo.name'.
 // Use `Root` key to get the whole configure.
-// If it doesn't exist, 'Value.IsNil()' is true.
-// TODO: How if the value is set to nil, should tell the difference between not-exist and nil value?
+//
+// The result is tri-state: missing, null and value.
+// If it doesn't exist or it's deleted, 'Value.IsMissing()' is true.
+// If it's set to null, e.g. 'name: null' in yaml or '-foo.name=' in commandline, 'Value.IsNull()' is true.
+// 'Value.IsNil()' is true for both missing and null.
 func (c *OlayConfig) Get(key string) Value {
-	var cur any = c.merged
-	if key == Root {
-		return Value{v: cur}
-	}
-	sps := strings.Split(key, ".")
-	for _, sp := range sps {
-		var ok bool
-		var curM map[any]any
-		if curM, ok = cur.(map[any]any); !ok {
-			cur = nil
-			break
-		}
-		if cur, ok = curM[sp]; !ok {
-			cur = nil
-			break
+	return lookupValue(c.resolvedTree(), c.keyNorm.path(key))
+}
+
+// Return the value of the key path in the configure tree m, return false if it doesn't exist or it's deleted.
+// The `Root` key returns m itself. The deleted keys are pruned from the returned sub-tree.
+func lookup(m map[any]any, key string) (any, bool) {
+	var cur any = m
+	if key != Root {
+		for _, seg := range parseKeyPath(key) {
+			var ok bool
+			if seg.isIndex {
+				var arr []any
+				if arr, ok = cur.([]any); !ok || seg.index >= len(arr) {
+					return nil, false
+				}
+				cur = arr[seg.index]
+				continue
+			}
+			var curM map[any]any
+			if curM, ok = cur.(map[any]any); !ok {
+				return nil, false
+			}
+			if cur, ok = mapValue(curM, seg.key); !ok {
+				return nil, false
+			}
 		}
 	}
-	return Value{v: cur}
+	if isDeleted(cur) {
+		return nil, false
+	}
+	return prune(cur), true
+}
+
+// Return the tri-state Value of the key path in the configure tree m.
+func lookupValue(m map[any]any, key string) Value {
+	v, ok := lookup(m, key)
+	if !ok {
+		return Value{}
+	}
+	if v == nil {
+		return Value{null}
+	}
+	return Value{v}
 }
 
 // Get string value, return defaultValue if it doesn't exisit.
@@ -299,6 +648,7 @@ func (c *OlayConfig) Unmarshal(key string, out any) error {
 	if v.IsNil() {
 		return errors.Errorf("key doesn't exists: %v", key)
 	}
+	v = Value{c.keyNorm.align(v.raw(), reflect.TypeOf(out))}
 	return v.Unmarshal(out)
 }
 
@@ -320,50 +670,130 @@ var defaultC = New()
 // - Enviroments, e.g. FOO_NAME=hello
 // - Yaml files, e.g. `-oc.f.y=foo.yaml`
 // - Json files, e.g. `-oc.f.j=foo.json`
+// - Toml files, e.g. `-oc.f.t=foo.toml`
+// - Ini files, e.g. `-oc.f.ini=foo.ini`
+// - Java properties files, e.g. `-oc.f.prop=foo.properties`
+// - Dotenv files, e.g. `-oc.f.env=.env`
+// - Directories, e.g. `-oc.dir=/etc/app/conf.d`
+// - Key-per-file directories, e.g. `-oc.kdir=/etc/config`
+// - Http(s) urls, e.g. `-oc.url=https://config.example.com/app.yaml`
+//
+// With profile `-oc.profile=prod` or `OLAYC_PROFILE=prod`, the variant `<name>.prod.<ext>` of each file is loaded above the file,
+// and the in-file profile sections are applied, refer to `ProfilesKey`.
+//
+// The file flags accept glob patterns, e.g. `-oc.f.y=/etc/app/conf.d/*.yaml`, and '-' reads from stdin, e.g. `-oc.f.y=-`.
+// The files expanded from a glob pattern or a directory are ordered lexically, the latter file wins.
 //
 // If errors happen, e.g. load file fail, error message will be printed and call os.Exit(1).
 func Load(opts ...loadOptionFunc) {
-	type inputFileType int
-	const (
-		Yaml inputFileType = iota
-		Json
-	)
-	type inputFile struct {
-		name string
-		typ  inputFileType
-	}
-
 	var helpOC = false
 	var helpApp = false
 	var verbose = false
 	var dryrun = false
+	var explain = false
 	var ifEnv = false
-	var files []inputFile
+	var profile = ""
+	var files []Source
+	var profileFiles []string
 
 	var opt loadOptions
 	for _, of := range opts {
 		of(&opt)
 	}
+	for _, b := range opt.envBindings {
+		defaultC.BindEnv(b.key, b.names...)
+	}
+
+	// Add files with the source constructor.
+	// The glob pattern is expanded in lexical order, the latter matched file wins.
+	// The `Stdin` path '-' reads from standard input, it can be used only once.
+	var stdinUsed = false
+	addFiles := func(pattern string, newSource func(string) Source) {
+		if pattern == Stdin {
+			if stdinUsed {
+				fmt.Println("[OlayConfig][Error] Stdin '-' can be used only once.")
+				os.Exit(1)
+			}
+			stdinUsed = true
+		}
+		names, err := expandFiles(pattern)
+		if err != nil {
+			fmt.Printf("[OlayConfig][Error] Load fail, error: %v\n", err)
+			os.Exit(1)
+		}
+		for i := len(names) - 1; i >= 0; i-- {
+			// The profile variant is overlayed above the base file.
+			if pf := profileFileExists(names[i], profile); len(pf) > 0 {
+				files = append(files, newSource(pf))
+				profileFiles = append(profileFiles, pf)
+			}
+			files = append(files, newSource(names[i]))
+		}
+	}
 
 	fpsr := &flagParser{}
 	fpsr.parse(os.Args[1:])
+	// The empty internal flag, e.g. '-oc.f.y=', is the zero value of its kind.
+	for i, kv := range fpsr.kvs {
+		if fl, ok := internalFlagOf(kv.key); ok && kv.value == nil {
+			fpsr.kvs[i].value = fl.zero()
+		}
+	}
+
+	// Profile must be known before files are added, `-oc.profile` is prior to the environment.
+	profile = os.Getenv(profileEnv)
+	for _, kv := range fpsr.kvs {
+		if internalFlags["profile"].is(kv.key) {
+			profile = fmt.Sprintf("%v", kv.value)
+		}
+	}
+
 	for _, kv := range fpsr.kvs {
 		// Handle internal flags.
 		if internalFlags["verbose"].is(kv.key) {
 			verbose = kv.value.(bool)
 		} else if internalFlags["env"].is(kv.key) {
 			ifEnv = kv.value.(bool)
+		} else if internalFlags["env.prefix"].is(kv.key) {
+			opt.envPrefix = kv.value.(string)
+		} else if internalFlags["env.separator"].is(kv.key) {
+			opt.envSeparator = kv.value.(string)
 		} else if internalFlags["help"].is(kv.key) {
 			helpOC = kv.value.(bool)
 		} else if internalFlags["dryrun"].is(kv.key) {
 			dryrun = kv.value.(bool)
+		} else if internalFlags["explain"].is(kv.key) {
+			explain = kv.value.(bool)
 		} else if internalFlags["file.yaml"].is(kv.key) {
-			files = append(files, inputFile{kv.value.(string), Yaml})
+			addFiles(kv.value.(string), YamlFileSource)
 		} else if internalFlags["file.json"].is(kv.key) {
-			files = append(files, inputFile{kv.value.(string), Json})
+			addFiles(kv.value.(string), JsonFileSource)
+		} else if internalFlags["file.toml"].is(kv.key) {
+			addFiles(kv.value.(string), TomlFileSource)
+		} else if internalFlags["file.ini"].is(kv.key) {
+			addFiles(kv.value.(string), IniFileSource)
+		} else if internalFlags["file.properties"].is(kv.key) {
+			addFiles(kv.value.(string), PropertiesFileSource)
+		} else if internalFlags["file.env"].is(kv.key) {
+			addFiles(kv.value.(string), DotenvFileSource)
+		} else if internalFlags["dir"].is(kv.key) {
+			srcs, err := DirSources(kv.value.(string))
+			if err != nil {
+				fmt.Printf("[OlayConfig][Error] Load directory fail, error: %v\n", err)
+				os.Exit(1)
+			}
+			files = append(files, srcs...)
+		} else if internalFlags["url"].is(kv.key) {
+			files = append(files, HttpSource(kv.value.(string), defaultHttpTimeout, defaultHttpCacheDir()))
+		} else if internalFlags["kdir"].is(kv.key) {
+			files = append(files, KeyDirSource(kv.value.(string)))
+		} else if internalFlags["profile"].is(kv.key) {
+			// Handled before.
+		} else if internalFlags["unset"].is(kv.key) {
+			// Handled by the args source.
 		} else if strings.HasPrefix(kv.key, internalFlagPrefix) {
 			fmt.Printf("[OlayConfig][Error] Unknown oc flag: %v\n", kv.key)
-			usageOlayc()
+			usageOlayc(defaultC.envBindings)
 			os.Exit(1)
 		}
 
@@ -373,20 +803,35 @@ func Load(opts ...loadOptionFunc) {
 		}
 	}
 
+	// The prefix turns on environments.
+	if len(opt.envPrefix) > 0 {
+		ifEnv = true
+	}
+
 	if helpOC {
-		usageOlayc()
+		usageOlayc(defaultC.envBindings)
 		os.Exit(0)
 	}
 
 	if helpApp {
-		usageApp(opt.usageEntries)
+		usageApp(opt.usageEntries, defaultC.envBindings)
 		os.Exit(0)
 	}
 
 	if verbose {
 		fmt.Printf("[OlayConfig] Verbose: %v. (use -oc.v)\n", verbose)
 		fmt.Printf("[OlayConfig] Load ENVs: %v. (use -oc.e)\n", ifEnv)
+		fmt.Printf("[OlayConfig] ENV prefix: %v. (use -oc.ep)\n", opt.envPrefix)
+		fmt.Printf("[OlayConfig] ENV separator: %v. (use -oc.es)\n", opt.envSeparator)
+		for _, b := range defaultC.envBindings {
+			fmt.Printf("[OlayConfig] ENV binding: %v.\n", b)
+		}
 		fmt.Printf("[OlayConfig] Dry run: %v. (use -oc.dr)\n", dryrun)
+		fmt.Printf("[OlayConfig] Explain: %v. (use -oc.x)\n", explain)
+		fmt.Printf("[OlayConfig] Profile: %v. (use -oc.profile or %v)\n", profile, profileEnv)
+		for _, pf := range profileFiles {
+			fmt.Printf("[OlayConfig] Profile file picked: %v.\n", pf)
+		}
 	}
 
 	if len(opt.filesRequired) > 0 && verbose {
@@ -406,7 +851,7 @@ func Load(opts ...loadOptionFunc) {
 	for _, fr := range opt.filesRequired {
 		ok := false
 		for i := 0; i < len(files) && !ok; i++ {
-			ok = strings.HasSuffix(files[i].name, fr)
+			ok = strings.HasSuffix(files[i].Name(), fr)
 		}
 		if !ok {
 			fmt.Printf("[OlayConfig][Error] Required file \"%v\" is not provided.\n", fr)
@@ -414,54 +859,80 @@ func Load(opts ...loadOptionFunc) {
 		}
 	}
 	if !checkPass {
-		fmt.Println("[OlayConfig][Error] Add required files using '-oc.f.(y|j)=....'.")
+		fmt.Println("[OlayConfig][Error] Add required files using '-oc.f.(y|j|t|ini|prop|env)=....'.")
 		os.Exit(1)
 	}
 
-	// Load commandline arguments
-	n, err := defaultC.LoadArgs(os.Args[1:])
-	if err != nil {
-		fmt.Printf("[OlayConfig][Error] Load arguments fail, error: %v]\n", err)
-		os.Exit(1)
+	// Register sources, they are loaded ordered by priority.
+	defaultC.AddSource(ArgsSource(os.Args[1:]))
+	// The bound environment variables are overlayed above the other environments.
+	if len(defaultC.envBindings) > 0 {
+		defaultC.AddSource(defaultC.EnvBindingsSource())
 	}
-	if verbose {
-		fmt.Printf("[OlayConfig] Commandlines loaded, totally %v KVs.\n", n)
-	}
-
-	// Load ENVs
 	if ifEnv {
-		n, err = defaultC.LoadEnvs(os.Environ())
-		if err != nil {
-			fmt.Printf("[OlayConfig][Error] Load environments fail, error: %v]\n", err)
-			os.Exit(1)
-		}
-		if verbose {
-			fmt.Printf("[OlayConfig] Environments loaded, totally %v KVs.\n", n)
+		defaultC.AddSource(PrefixedEnvsSource(os.Environ(), opt.envPrefix, opt.envSeparator))
+	}
+	for _, f := range files {
+		defaultC.AddSource(ProfileSource(f, profile))
+	}
+	for _, src := range opt.sources {
+		if _, ok := src.(*fsSource); ok {
+			src = ProfileSource(src, profile)
 		}
+		defaultC.AddSource(src)
 	}
 
-	// Load yaml/json files
-	for _, f := range files {
-		var err error
-		if f.typ == Yaml {
-			err = defaultC.LoadYamlFile(f.name)
-		} else if f.typ == Json {
-			err = defaultC.LoadJsonFile(f.name)
+	// Override priorities.
+	for i, src := range defaultC.sources {
+		for _, np := range opt.priorities {
+			if matchSourceName(src.Name(), np.name) {
+				defaultC.sources[i] = SourceWithPriority(src, np.priority)
+				src = defaultC.sources[i]
+			}
 		}
+	}
+
+	defaultC.SetKeyNormalization(opt.keyNorm)
+	for scheme, r := range opt.resolvers {
+		defaultC.RegisterResolver(scheme, r)
+	}
+	defaultC.SetArrayMerge(opt.arrayMerge)
+	for key, strategy := range opt.arrayMergeAt {
+		defaultC.SetArrayMergeAt(key, strategy)
+	}
+
+	srcs := make([]Source, len(defaultC.sources))
+	copy(srcs, defaultC.sources)
+	sortSources(srcs)
+	for _, src := range srcs {
+		err := defaultC.LoadSource(src)
 		if err != nil {
 			fmt.Printf("[OlayConfig][Error] Load fail, error: %v\n", err)
 			os.Exit(1)
 		}
 		if verbose {
-			fmt.Printf("[OlayConfig] File loaded: %v.\n", f.name)
+			fmt.Printf("[OlayConfig] Source loaded: %v (priority %v).\n", src.Name(), src.Priority())
 		}
 	}
 
+	// Placeholders are resolved after all sources are loaded.
+	err := defaultC.Resolve()
+	if err != nil {
+		fmt.Printf("[OlayConfig][Error] Resolve fail, error: %v\n", err)
+		os.Exit(1)
+	}
+
 	if dryrun {
 		fmt.Println("[OlayConfig] Dry run mode is on, program will exit after yaml printed.")
 		fmt.Printf("%v", defaultC.ToYaml())
 		os.Exit(0)
 	}
+
+	if explain {
+		fmt.Println("[OlayConfig] Explain mode is on, program will exit after keys and origins printed.")
+		fmt.Printf("%v", defaultC.explain())
+		os.Exit(0)
+	}
 }
 
 // Get value with default OlaycConfig.
@@ -513,3 +984,28 @@ func Unmarshal(key string, out any) error {
 func ToYaml() string {
 	return defaultC.ToYaml()
 }
+
+// Query the keys matching the pattern with default OlayConfig, refer to `OlayConfig.Query()`.
+func Query(pattern string) []QueryResult {
+	return defaultC.Query(pattern)
+}
+
+// OriginOf returns the origin of key with default OlayConfig, refer to `OlayConfig.Origin()`.
+func OriginOf(key string) Origin {
+	return defaultC.Origin(key)
+}
+
+// Layers with default OlayConfig.
+func Layers() []*Layer {
+	return defaultC.Layers()
+}
+
+// LayerOf returns the layer matching name with default OlayConfig, refer to `OlayConfig.Layer()`.
+func LayerOf(name string) *Layer {
+	return defaultC.Layer(name)
+}
+
+// Diff with default OlayConfig.
+func Diff(a string, b string) (*LayerDiff, error) {
+	return defaultC.Diff(a, b)
+}
@@ -0,0 +1,158 @@
+package olayc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// consulKV is an entry of consul KV API response.
+type consulKV struct {
+	Key         string
+	Value       *string
+	ModifyIndex uint64
+}
+
+// ConsulKVSource is a Source reading all keys under a prefix from consul KV with the HTTP API.
+//
+// The keys are converted by trimming the prefix and replacing '/' with '.',
+// e.g. with prefix 'app/', key 'app/foo/redis/host' is converted to 'foo.redis.host'.
+// The prefix is a directory, e.g. 'app' is the same as 'app/', it doesn't match 'apple/x'.
+// The values are base64 decoded, value interpretation should refer to `interpret(string)`.
+//
+// The index of the last response is tracked, `WaitChange()` uses it to make blocking query for reloads.
+type ConsulKVSource struct {
+	addr     string
+	prefix   string
+	token    string
+	priority int
+	client   *http.Client
+	index    uint64
+}
+
+// ConsulSource returns a Source reading all keys under the prefix from consul KV.
+// The addr is the consul HTTP address, e.g. 'http://127.0.0.1:8500', the token is the ACL token which can be empty.
+func ConsulSource(addr string, prefix string, token string) *ConsulKVSource {
+	return &ConsulKVSource{
+		addr:     strings.TrimRight(addr, "/"),
+		prefix:   strings.TrimLeft(prefix, "/"),
+		token:    token,
+		priority: PriorityFile,
+		client:   &http.Client{},
+	}
+}
+
+func (s *ConsulKVSource) Name() string  { return "consul:" + s.prefix }
+func (s *ConsulKVSource) Priority() int { return s.priority }
+func (s *ConsulKVSource) Load() (map[any]any, error) {
+	kvs, err := s.kvs()
+	if err != nil {
+		return nil, err
+	}
+	return kvsToMap(kvs), nil
+}
+
+// Index returns the consul index of the last response, it's 0 if never loaded.
+func (s *ConsulKVSource) Index() uint64 {
+	return s.index
+}
+
+// WaitChange makes blocking query with the index of the last response,
+// it returns true if the keys under the prefix are changed, or false if wait timeout.
+// Call `Load()` to load the changed keys.
+func (s *ConsulKVSource) WaitChange(wait time.Duration) (bool, error) {
+	last := s.index
+	_, err := s.query(last, wait)
+	if err != nil {
+		return false, err
+	}
+	return s.index != last, nil
+}
+
+// Read kvs under the prefix, ordered by key.
+func (s *ConsulKVSource) kvs() ([]KV, error) {
+	entries, err := s.query(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []KV
+	for _, entry := range entries {
+		// Folders have no values.
+		if entry.Value == nil {
+			continue
+		}
+		key := strings.Trim(strings.TrimPrefix(entry.Key, dirPrefix(s.prefix)), "/")
+		if len(key) == 0 {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(*entry.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of key %v", entry.Key)
+		}
+		kvs = append(kvs, KV{strings.ReplaceAll(key, "/", "."), interpret(string(value))})
+	}
+	return kvs, nil
+}
+
+// Make recursive query under the prefix, and track the index of the response.
+// If index is not 0, it's a blocking query waits at most `wait` for the index changing.
+func (s *ConsulKVSource) query(index uint64, wait time.Duration) ([]consulKV, error) {
+	q := url.Values{}
+	q.Set("recurse", "true")
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", wait.String())
+	}
+
+	// Consul adds a jitter up to wait/16 to blocking query.
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHttpTimeout+wait+wait/16)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.addr+"/v1/kv/"+dirPrefix(s.prefix)+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.token) > 0 {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if idx, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64); err == nil {
+		s.index = idx
+	}
+
+	// No keys under the prefix.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status: %v", resp.Status)
+	}
+
+	var entries []consulKV
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Return the prefix ending with '/', thus it matches only the keys in the directory. The empty prefix matches all keys.
+func dirPrefix(prefix string) string {
+	if len(prefix) == 0 || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix + "/"
+}
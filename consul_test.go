@@ -0,0 +1,187 @@
+package olayc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul is a fake consul KV HTTP server.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	kvs     map[string]string
+	changed chan struct{}
+}
+
+func (f *fakeConsul) put(key string, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	f.kvs[key] = value
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "secret" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+	// Blocking query
+	if index, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); err == nil {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		f.mu.Lock()
+		changed, cur := f.changed, f.index
+		f.mu.Unlock()
+		if index == cur {
+			select {
+			case <-changed:
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var entries []map[string]any
+	for k, v := range f.kvs {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		entry := map[string]any{"Key": k, "ModifyIndex": f.index}
+		if !strings.HasSuffix(k, "/") {
+			entry["Value"] = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		entries = append(entries, entry)
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+func TestConsulSource(t *testing.T) {
+	fake := &fakeConsul{
+		index: 10,
+		kvs: map[string]string{
+			"app/":               "",
+			"app/foo/name":       "foo-consul",
+			"app/foo/id":         "123",
+			"app/foo/redis/host": "redis.cluster",
+			"app/foo/redis/port": "6380",
+			"other/foo/name":     "foo-other",
+			"apple/color":        "red",
+		},
+		changed: make(chan struct{}),
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	var c = New()
+	n, err := c.LoadConsul(srv.URL, "app/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("got(%v)!=expect(%v) kvs loaded\n", n, 4)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"foo.name", "foo-consul"},
+		{"foo.id", uint64(123)},
+		{"foo.redis.host", "redis.cluster"},
+		{"foo.redis.port", uint64(6380)},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key=%v, got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+
+	// Prefix without trailing '/' doesn't match the sibling 'apple/'
+	m, err := ConsulSource(srv.URL, "app", "secret").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["le"]; ok {
+		t.Errorf("expect no key of sibling prefix, got %v", m)
+	}
+	if _, ok := m["foo"]; !ok {
+		t.Errorf("expect key foo, got %v", m)
+	}
+
+	// Empty prefix
+	m, err = ConsulSource(srv.URL, "not-exist/", "secret").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 0 {
+		t.Errorf("expect empty tree, got %v", m)
+	}
+
+	// Forbidden
+	if _, err = ConsulSource(srv.URL, "app/", "").Load(); err == nil {
+		t.Error("expect error without token")
+	}
+}
+
+func TestConsulSourceWaitChange(t *testing.T) {
+	fake := &fakeConsul{
+		index:   1,
+		kvs:     map[string]string{"app/foo/name": "foo1"},
+		changed: make(chan struct{}),
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	src := ConsulSource(srv.URL, "/app", "secret")
+	_, err := src.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Index() != 1 {
+		t.Fatalf("got(%v)!=expect(%v) index\n", src.Index(), 1)
+	}
+
+	changed, err := src.WaitChange(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Fatal("expect not changed")
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		fake.put("app/foo/name", "foo2")
+	}()
+	changed, err = src.WaitChange(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || src.Index() != 2 {
+		t.Fatalf("expect changed to index 2, got changed(%v) index(%v)", changed, src.Index())
+	}
+
+	var c = New()
+	err = c.LoadSource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo2" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo2")
+	}
+}
@@ -0,0 +1,119 @@
+package olayc
+
+import (
+	"bytes"
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// deleteMarker is the type of `Delete`.
+type deleteMarker struct{}
+
+// Delete is the deletion marker, a key set to Delete is removed, and the key of lower layers is shadowed, e.g.
+//
+//	foo:
+//	  redis: !delete
+//
+// The key 'foo.redis' is missing even if the lower layers set it.
+// The deletion marker is set by the '!delete' tag in yaml, or `-oc.unset=foo.redis` in commandline arguments.
+// Custom sources can set keys to Delete in the loaded maps.
+var Delete any = deleteMarker{}
+
+// deleteTag is the yaml tag of deletion marker.
+const deleteTag = "!delete"
+
+// Return if v is the deletion marker.
+func isDeleted(v any) bool {
+	_, ok := v.(deleteMarker)
+	return ok
+}
+
+// Set the values tagged with '!delete' in yaml data to `Delete`, m is decoded from data.
+// Only the values of mappings are supported, the tags in sequences are ignored.
+func applyYamlDeletes(data []byte, m map[any]any) error {
+	if !bytes.Contains(data, []byte(deleteTag)) {
+		return nil
+	}
+	var doc yamlv3.Node
+	err := yamlv3.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	markYamlDeletes(doc.Content[0], m)
+	return nil
+}
+
+// Walk the yaml mapping node and the decoded map at the same time, set the values tagged with '!delete' to `Delete`.
+func markYamlDeletes(node *yamlv3.Node, m map[any]any) {
+	if node.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		for k, v := range m {
+			if fmt.Sprint(k) != keyNode.Value {
+				continue
+			}
+			if valueNode.Tag == deleteTag {
+				m[k] = Delete
+			} else if sub, ok := v.(map[any]any); ok {
+				markYamlDeletes(valueNode, sub)
+			}
+			break
+		}
+	}
+}
+
+// Return deep copy of v without deleted keys, and the array holes are null.
+// v is returned as it is if there is nothing to prune.
+func prune(v any) any {
+	if !needsPrune(v) {
+		return v
+	}
+	switch x := v.(type) {
+	case map[any]any:
+		m := make(map[any]any, len(x))
+		for k, sub := range x {
+			if !isDeleted(sub) {
+				m[k] = prune(sub)
+			}
+		}
+		return m
+	case []any:
+		sl := make([]any, 0, len(x))
+		for _, sub := range x {
+			if !isDeleted(sub) {
+				sl = append(sl, prune(sub))
+			}
+		}
+		return sl
+	case arrayHole:
+		return nil
+	}
+	return v
+}
+
+// Return if v contains any deleted key or array hole.
+func needsPrune(v any) bool {
+	switch x := v.(type) {
+	case deleteMarker, arrayHole:
+		return true
+	case map[any]any:
+		for _, sub := range x {
+			if needsPrune(sub) {
+				return true
+			}
+		}
+	case []any:
+		for _, sub := range x {
+			if needsPrune(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
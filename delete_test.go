@@ -0,0 +1,121 @@
+package olayc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYamlDelete(t *testing.T) {
+	m, err := decodeYaml([]byte(`
+foo:
+  name: !delete
+  redis: !delete
+    host: localhost
+  id: 1
+bar: !delete ""
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[any]any{
+		"foo": map[any]any{
+			"name":  Delete,
+			"redis": Delete,
+			"id":    1,
+		},
+		"bar": Delete,
+	}
+	if !reflect.DeepEqual(m, expect) {
+		t.Errorf("got(%v)!=expect(%v)\n", m, expect)
+	}
+}
+
+func TestGetTriState(t *testing.T) {
+	c := New()
+	err := c.LoadYaml([]byte(`
+foo:
+  name: foo1
+  nothing: null
+  deleted: !delete
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key     string
+		missing bool
+		null    bool
+	}{
+		{"foo.name", false, false},
+		{"foo.nothing", false, true},
+		{"foo.deleted", true, false},
+		{"foo.notexist", true, false},
+	} {
+		v := c.Get(test.key)
+		if v.IsMissing() != test.missing || v.IsNull() != test.null || v.IsNil() != (test.missing || test.null) {
+			t.Errorf("[%v] key(%v) missing(%v) null(%v) nil(%v)\n", i, test.key, v.IsMissing(), v.IsNull(), v.IsNil())
+		}
+	}
+	if got := c.String("foo.nothing", "default"); got != "default" {
+		t.Errorf("got(%v)!=expect(default)\n", got)
+	}
+}
+
+func TestOverlayDelete(t *testing.T) {
+	c := New()
+	c.AddSource(ArgsSource([]string{
+		"-oc.unset=foo.url",
+		"-foo.id=",
+		"-foo.labels.app=foo",
+	}))
+	c.AddSource(SourceWithPriority(YamlSource([]byte(`
+foo:
+  name: !delete
+  labels: !delete
+  redis: null
+  servers: !delete
+`)), PriorityEnv))
+	c.AddSource(YamlSource([]byte(`
+foo:
+  name: foo1
+  id: 1
+  url: http://www.example.com
+  labels:
+    zone: sz
+  redis:
+    host: localhost
+  servers: [s1, s2]
+  port: 80
+`)))
+	c.SetArrayMerge(MergeAppend)
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect Value
+	}{
+		{"foo.name", Value{}},
+		{"foo.url", Value{}},
+		{"foo.id", Value{null}},
+		{"foo.redis", Value{null}},
+		{"foo.redis.host", Value{}},
+		{"foo.servers", Value{}},
+		{"foo.port", Value{80}},
+		// The upper sub-tree is kept, the lower sub-tree is deleted.
+		{"foo.labels", Value{map[any]any{"app": "foo"}}},
+	} {
+		got := c.Get(test.key)
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got, test.expect)
+		}
+	}
+
+	yml := c.ToYaml()
+	if strings.Contains(yml, "name") || strings.Contains(yml, "url") {
+		t.Errorf("Deleted keys in yaml:\n%v\n", yml)
+	}
+}
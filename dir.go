@@ -0,0 +1,76 @@
+package olayc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Return if the path contains any glob meta characters.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// Expand glob pattern to file names in lexical order.
+// If it's not a glob pattern, it's returned as is.
+// Return error if the pattern is malformed or there are no matched files.
+func expandFiles(pattern string) ([]string, error) {
+	if !isGlob(pattern) {
+		return []string{pattern}, nil
+	}
+	names, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern %v", pattern)
+	}
+
+	var files []string
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		files = append(files, name)
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no files match pattern %v", pattern)
+	}
+	return files, nil
+}
+
+// Return config files in the directory in lexical order, e.g. '/etc/app/conf.d'.
+// Only files with known extensions are returned, refer to `formatOf()`.
+// Sub-directories and hidden files(prefixed with '.') are skipped.
+func dirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || len(formatOf(name)) == 0 {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// DirSources returns sources loading config files in the directory, each file is one source.
+// The files are ordered lexically, the latter file wins, e.g. '90-local.yaml' is overlayed above '10-base.yaml'.
+// The returned sources are ordered from top to bottom.
+func DirSources(dir string) ([]Source, error) {
+	files, err := dirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var srcs []Source
+	for i := len(files) - 1; i >= 0; i-- {
+		srcs = append(srcs, &fileSource{files[i], PriorityFile, formatOf(files[i]), nil, nil})
+	}
+	return srcs, nil
+}
@@ -0,0 +1,102 @@
+package olayc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Create files in a temporary directory, return the directory.
+func createTestDir(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandFiles(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"conf.d/20-b.yaml":   "",
+		"conf.d/10-a.yaml":   "",
+		"conf.d/30-c.json":   "",
+		"conf.d/sub.yaml/x":  "",
+		"conf.d/99-z.yaml~":  "",
+		"conf.d/05-pre.yaml": "",
+	})
+
+	got, err := expandFiles(filepath.Join(dir, "conf.d", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expect = []string{
+		filepath.Join(dir, "conf.d", "05-pre.yaml"),
+		filepath.Join(dir, "conf.d", "10-a.yaml"),
+		filepath.Join(dir, "conf.d", "20-b.yaml"),
+	}
+	if !reflect.DeepEqual(expect, got) {
+		t.Fatalf("expect(%v)!=got(%v)\n", expect, got)
+	}
+
+	got, err = expandFiles("foo.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"foo.yaml"}, got) {
+		t.Fatalf("expect(%v)!=got(%v)\n", []string{"foo.yaml"}, got)
+	}
+
+	_, err = expandFiles(filepath.Join(dir, "conf.d", "*.toml"))
+	if err == nil {
+		t.Fatal("expect error if no files match")
+	}
+}
+
+func TestConfigLoadDir(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"10-base.yaml":       "foo: {name: foo-base, id: 1, url: http://www.base.com}",
+		"20-redis.json":      `{"foo": {"id": 2, "redis": {"host": "redis.json"}}}`,
+		"90-local.toml":      "[foo]\nname = \"foo-local\"",
+		"README.md":          "# Ignored",
+		".hidden.yaml":       "foo: {name: foo-hidden}",
+		"sub/50-sub.yaml":    "foo: {name: foo-sub}",
+		"30-broken.yaml~":    "foo: [",
+		"40-port.ini":        "[foo.redis]\nport = 6380",
+		"45-conf.properties": "foo.redis.host=redis.properties",
+	})
+
+	var c = New()
+	err := c.LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"foo.name", "foo-local"},
+		{"foo.id", "2"},
+		{"foo.url", "http://www.base.com"},
+		{"foo.redis.host", "redis.properties"},
+		{"foo.redis.port", "6380"},
+	} {
+		got := c.String(test.key, "")
+		if got != test.expect {
+			t.Errorf("[%v] key=%v, got(\"%v\")!=expect(\"%v\")\n", i, test.key, got, test.expect)
+		}
+	}
+
+	if err = c.LoadDir(filepath.Join(dir, "not-exist")); err == nil {
+		t.Error("expect error loading not exist directory")
+	}
+}
@@ -0,0 +1,321 @@
+package olayc
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// dotenvParser parses dotenv(.env) file to environments in the form "key=value".
+// The supported syntax:
+//
+//	# Comment line
+//	FOO_NAME=foo                 # Inline comment
+//	export FOO_ID=123            # `export` prefix is ignored
+//	FOO_RAW='${NOT_EXPANDED}\n'  # Single quotes, value is literal
+//	FOO_MSG="hello\nworld"       # Double quotes, escapes \n \r \t \" \\ \$ are supported
+//	FOO_URL=http://${FOO_HOST}   # ${VAR} and $VAR are expanded in unquoted and double quoted values
+//	FOO_LONG=first \
+//	second                       # Escaped newline continues the value
+//
+// The expanded variables are looked up in the previously parsed keys, then in `lookup`.
+// If a key is defined more than once, the later definition wins.
+type dotenvParser struct {
+	data   string
+	pos    int
+	line   int
+	lookup func(string) (string, bool)
+	vars   map[string]string
+	envs   []string
+	lines  map[string]int
+}
+
+// Parse dotenv bytes, return error with line number if syntax error.
+func (psr *dotenvParser) parse(data []byte) error {
+	psr.data = string(data)
+	psr.pos = 0
+	psr.line = 1
+	psr.vars = make(map[string]string)
+	psr.lines = make(map[string]int)
+	for {
+		psr.skipBlank()
+		if psr.eof() {
+			return nil
+		}
+		if err := psr.parseOne(); err != nil {
+			return errors.Wrapf(err, "line %v", psr.line)
+		}
+	}
+}
+
+func (psr *dotenvParser) eof() bool {
+	return psr.pos >= len(psr.data)
+}
+
+func (psr *dotenvParser) peek() byte {
+	return psr.data[psr.pos]
+}
+
+func (psr *dotenvParser) next() byte {
+	c := psr.data[psr.pos]
+	psr.pos++
+	if c == '\n' {
+		psr.line++
+	}
+	return c
+}
+
+// Skip spaces, empty lines and comment lines.
+func (psr *dotenvParser) skipBlank() {
+	for !psr.eof() {
+		c := psr.peek()
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			psr.next()
+		} else if c == '#' {
+			psr.skipLine()
+		} else {
+			break
+		}
+	}
+}
+
+// Skip spaces in the current line.
+func (psr *dotenvParser) skipSpaces() {
+	for !psr.eof() && (psr.peek() == ' ' || psr.peek() == '\t') {
+		psr.next()
+	}
+}
+
+// Skip to the end of current line.
+func (psr *dotenvParser) skipLine() {
+	for !psr.eof() && psr.peek() != '\n' {
+		psr.next()
+	}
+}
+
+// Parse one "key=value" entry.
+func (psr *dotenvParser) parseOne() error {
+	line := psr.line
+	key := psr.parseKey()
+	if key == "export" && !psr.eof() && (psr.peek() == ' ' || psr.peek() == '\t') {
+		psr.skipSpaces()
+		key = psr.parseKey()
+	}
+	if len(key) == 0 {
+		return errors.Errorf("invalid key")
+	}
+	psr.skipSpaces()
+	if psr.eof() || psr.peek() != '=' {
+		return errors.Errorf("missing '=' after key %v", key)
+	}
+	psr.next()
+	psr.skipSpaces()
+
+	var value string
+	var err error
+	if !psr.eof() && psr.peek() == '\'' {
+		value, err = psr.parseSingleQuoted()
+	} else if !psr.eof() && psr.peek() == '"' {
+		value, err = psr.parseDoubleQuoted()
+	} else {
+		value = psr.parseUnquoted()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only comment is allowed after quoted value.
+	psr.skipSpaces()
+	if !psr.eof() && psr.peek() != '\n' && psr.peek() != '\r' && psr.peek() != '#' {
+		return errors.Errorf("unexpected character '%c' after value of key %v", psr.peek(), key)
+	}
+	psr.skipLine()
+
+	// The later defined key wins, both for the value and the expansion.
+	psr.vars[key] = value
+	psr.lines[envKey(key)] = line
+	for i, e := range psr.envs {
+		if name, _, _ := strings.Cut(e, "="); envKey(name) == envKey(key) {
+			psr.envs[i] = key + "=" + value
+			return nil
+		}
+	}
+	psr.envs = append(psr.envs, key+"="+value)
+	return nil
+}
+
+// Return the environments with the placeholders in values escaped, e.g. 'FOO_RAW=$${NOT_EXPANDED}'.
+// The values are literal in interpolation, since the variables are expanded by the parser, refer to `Resolve()`.
+func (psr *dotenvParser) literalEnvs() []string {
+	envs := make([]string, len(psr.envs))
+	for i, e := range psr.envs {
+		name, value, _ := strings.Cut(e, "=")
+		envs[i] = name + "=" + escapePlaceholders(value)
+	}
+	return envs
+}
+
+// Parse key which is composed of letters, digits, '_', '.' and '-'.
+func (psr *dotenvParser) parseKey() string {
+	start := psr.pos
+	for !psr.eof() {
+		c := psr.peek()
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') &&
+			c != '_' && c != '.' && c != '-' {
+			break
+		}
+		psr.next()
+	}
+	return psr.data[start:psr.pos]
+}
+
+// Parse single quoted value, the value is literal.
+func (psr *dotenvParser) parseSingleQuoted() (string, error) {
+	psr.next() // Skip '\''
+	start := psr.pos
+	for !psr.eof() && psr.peek() != '\'' {
+		psr.next()
+	}
+	if psr.eof() {
+		return "", errors.Errorf("unterminated single quoted value")
+	}
+	value := psr.data[start:psr.pos]
+	psr.next() // Skip '\''
+	return value, nil
+}
+
+// Parse double quoted value, escapes and variables are handled.
+func (psr *dotenvParser) parseDoubleQuoted() (string, error) {
+	psr.next() // Skip '"'
+	var sb strings.Builder
+	for {
+		if psr.eof() {
+			return "", errors.Errorf("unterminated double quoted value")
+		}
+		c := psr.next()
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if psr.eof() {
+				return "", errors.Errorf("unterminated double quoted value")
+			}
+			e := psr.next()
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '\n':
+				// Escaped newline is joined.
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		case '$':
+			sb.WriteString(psr.parseVariable())
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// Parse unquoted value till the end of line or inline comment, trailing spaces are trimmed.
+func (psr *dotenvParser) parseUnquoted() string {
+	var sb strings.Builder
+	for !psr.eof() {
+		c := psr.peek()
+		if c == '\n' {
+			break
+		}
+		if c == '#' && (sb.Len() == 0 || strings.HasSuffix(sb.String(), " ") || strings.HasSuffix(sb.String(), "\t")) {
+			break
+		}
+		psr.next()
+		switch c {
+		case '\\':
+			if !psr.eof() && psr.peek() == '\n' {
+				// Escaped newline continues the value.
+				psr.next()
+			} else if !psr.eof() && psr.peek() == '$' {
+				sb.WriteByte(psr.next())
+			} else {
+				sb.WriteByte(c)
+			}
+		case '$':
+			sb.WriteString(psr.parseVariable())
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return strings.TrimRight(sb.String(), " \t\r")
+}
+
+// Parse variable after '$', in the form of "${VAR}" or "$VAR", return the expanded value.
+// If it's not a variable, '$' is kept as is.
+func (psr *dotenvParser) parseVariable() string {
+	braced := !psr.eof() && psr.peek() == '{'
+	start := psr.pos
+	if braced {
+		psr.next()
+	}
+	name := psr.parseName()
+	if braced {
+		if len(name) == 0 || psr.eof() || psr.peek() != '}' {
+			// Not a variable, restore position.
+			psr.pos = start
+			return "$"
+		}
+		psr.next()
+	} else if len(name) == 0 {
+		return "$"
+	}
+
+	if v, ok := psr.vars[name]; ok {
+		return v
+	}
+	if psr.lookup != nil {
+		if v, ok := psr.lookup(name); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Parse variable name which is composed of letters, digits and '_'.
+func (psr *dotenvParser) parseName() string {
+	start := psr.pos
+	for !psr.eof() {
+		c := psr.peek()
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' {
+			break
+		}
+		psr.next()
+	}
+	return psr.data[start:psr.pos]
+}
+
+// Decode dotenv bytes, the environments are converted to keys by `envParser`.
+// Variables are expanded with the previously defined keys, then the process environments.
+func decodeDotenv(data []byte) (map[any]any, error) {
+	psr := &dotenvParser{lookup: os.LookupEnv}
+	err := psr.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return newEnvsSource(psr.literalEnvs()).Load()
+}
+
+// Return line numbers of the converted dotenv keys, return nil if syntax error.
+func dotenvLines(data []byte) map[string]int {
+	psr := &dotenvParser{lookup: os.LookupEnv}
+	if psr.parse(data) != nil {
+		return nil
+	}
+	return psr.lines
+}
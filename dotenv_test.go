@@ -0,0 +1,151 @@
+package olayc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDotenvParser(t *testing.T) {
+	var testdata = []byte(`
+# Comment line
+FOO_NAME=foo1
+export FOO_ID=123 # Inline comment
+FOO_HASH=abc#def
+FOO_RAW='${FOO_NAME}\n'
+FOO_MSG="hello\nworld \"${FOO_NAME}\" \$FOO_ID"
+FOO_URL=http://$FOO_HOST:${FOO_PORT}/
+FOO_LONG=first \
+second
+FOO_MULTI="line1
+line2"
+FOO_EMPTY=
+  FOO_SPACE  =  spaced value
+`)
+
+	var env = map[string]string{
+		"FOO_HOST": "localhost",
+		"FOO_PORT": "8080",
+	}
+	psr := &dotenvParser{lookup: func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}}
+	err := psr.parse(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got = psr.envs
+	var expect = []string{
+		"FOO_NAME=foo1",
+		"FOO_ID=123",
+		"FOO_HASH=abc#def",
+		"FOO_RAW=${FOO_NAME}\\n",
+		"FOO_MSG=hello\nworld \"foo1\" $FOO_ID",
+		"FOO_URL=http://localhost:8080/",
+		"FOO_LONG=first second",
+		"FOO_MULTI=line1\nline2",
+		"FOO_EMPTY=",
+		"FOO_SPACE=spaced value",
+	}
+	if len(expect) != len(got) {
+		t.Logf("expect(len %v) %q\n", len(expect), expect)
+		t.Logf("got(len %v) %q\n", len(got), got)
+		t.FailNow()
+	}
+	for i := range expect {
+		if expect[i] != got[i] {
+			t.Errorf("[%v] expect(%q) != got(%q)", i, expect[i], got[i])
+		}
+	}
+}
+
+func TestDotenvParserError(t *testing.T) {
+	for i, test := range []string{
+		"FOO_NAME",
+		"FOO_NAME='foo",
+		"FOO_NAME=\"foo",
+		"FOO_NAME=\"foo\" bar",
+		"=foo",
+	} {
+		psr := &dotenvParser{}
+		err := psr.parse([]byte(test))
+		if err == nil {
+			t.Errorf("[%v] expect error parsing %q", i, test)
+		}
+	}
+}
+
+func TestConfigGetScalarLoadDotenv(t *testing.T) {
+	var c = New()
+	n, err := c.LoadDotenv([]byte(`
+FOO_NAME=foo-env
+FOO_REDIS_PORT=6380
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("got(%v)!=expect(%v) kvs loaded\n", n, 2)
+	}
+	if got := c.String("foo.name", ""); got != "foo-env" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-env")
+	}
+	if got := c.Int("foo.redis.port", 0); got != 6380 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 6380)
+	}
+}
+
+func TestDotenvRedefined(t *testing.T) {
+	c := New()
+	_, err := c.LoadDotenv([]byte("FOO=a\nBAR=${FOO}\nFOO=b\nBAZ=${FOO}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"foo", "b"},
+		{"bar", "a"},
+		{"baz", "b"},
+	} {
+		if got := c.String(test.key, ""); got != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got, test.expect)
+		}
+	}
+	if got := c.Origin("foo").Line; got != 3 {
+		t.Errorf("line got(%v)!=expect(3)\n", got)
+	}
+}
+
+func TestDotenvLiteral(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	err := os.WriteFile(path, []byte("FOO_RAW='${NOT}'\nFOO_COST='$${x}'\nFOO_MSG=\"raw ${FOO_RAW}\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The values are expanded by the dotenv parser, they're literal in interpolation.
+	c := New()
+	c.AddSource(DotenvFileSource(path))
+	c.AddSource(YamlSource([]byte("foo:\n  url: http://${foo.raw}\n")))
+	err = c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"foo.raw", "${NOT}"},
+		{"foo.cost", "$${x}"},
+		{"foo.msg", "raw ${NOT}"},
+		{"foo.url", "http://${NOT}"},
+	} {
+		if got := c.String(test.key, ""); got != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got, test.expect)
+		}
+	}
+}
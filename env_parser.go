@@ -7,40 +7,41 @@ import (
 // envParser parses from ENVs to kvs.
 type envParser struct {
 	kvs []KV
+	// prefix is the required prefix of names, it's stripped from the keys. All names are parsed if it's empty.
+	prefix string
+	// separator is the nesting seperator of names, it's `defaultEnvSeparator` if it's empty.
+	separator string
 }
 
+// defaultEnvSeparator is the default nesting seperator of environment names.
+const defaultEnvSeparator = "_"
+
 // Parse environments to kvs. The env must be in the form "key=value".
 // The key is converted to lower case and the seperator '_' is replaced by '.'.
 // E.g. 'LC_CTYPE=UTF-8', is converted to 'lc.ctype=UTF-8'.
 // The anterior '_' in key will be trimed, e.g. '_P9K_SSH_TTY' is converted to `p9k.ssh.tty`.
 //
+// If the prefix is set, e.g. 'MYAPP_', only the names with the prefix are parsed, and the prefix is stripped.
+// If the separator is set, e.g. '__', it's replaced by '.' instead of '_', e.g. 'FOO__MAX_CONNS' is converted to 'foo.max_conns',
+// and the number segments are array indexes, e.g. 'FOO__SERVERS__1__PORT' is converted to 'foo.servers[1].port'.
+// With the default separator the number segments are map keys, e.g. 'JAVA_HOME_8' is converted to 'java.home.8'.
+//
 // Value interpretation should refer to `func interpreted(string)`.
 func (psr *envParser) parse(envs []string) int {
-	// Replace '_' to '.', trim the anterior '_'.
-	replaceFunc := func(s string) string {
-		sl := []byte(s)
-		var i int
-		for i = 0; i < len(sl); i++ {
-			if sl[i] != '_' {
-				break
-			}
-		}
-		sl = sl[i:] // Trim anterior '_'
-		for i = 0; i < len(sl); i++ {
-			if sl[i] == '_' {
-				sl[i] = '.'
-			}
-		}
-		return string(sl)
-	}
-
 	for _, e := range envs {
 		sps := strings.SplitN(e, "=", 2)
 		if len(sps) != 2 {
 			continue
 		}
 
-		var key = strings.ToLower(replaceFunc(sps[0]))
+		name := sps[0]
+		if len(psr.prefix) > 0 {
+			if len(name) < len(psr.prefix) || !strings.EqualFold(name[:len(psr.prefix)], psr.prefix) {
+				continue
+			}
+			name = name[len(psr.prefix):]
+		}
+		var key = envKeyWithSeparator(name, psr.separator)
 		var value any = interpret(sps[1])
 		if len(key) > 0 {
 			psr.kvs = append(psr.kvs, KV{key, value})
@@ -48,3 +49,40 @@ func (psr *envParser) parse(envs []string) int {
 	}
 	return len(psr.kvs)
 }
+
+// Convert environment name to key, e.g. '_P9K_SSH_TTY' is converted to `p9k.ssh.tty`.
+// The anterior '_' is trimmed, and '_' is replaced by '.'.
+func envKey(name string) string {
+	return envKeyWithSeparator(name, defaultEnvSeparator)
+}
+
+// Convert environment name to key, the anterior '_' and separators are trimmed, and the separator is replaced by '.'.
+// The separator is '_' if it's empty. The number segments are array indexes only if the separator is not '_',
+// since the names like 'REDIS_PORT_6379_TCP_ADDR' are common.
+func envKeyWithSeparator(name string, separator string) string {
+	if len(separator) == 0 {
+		separator = defaultEnvSeparator
+	}
+	indexed := separator != defaultEnvSeparator
+	for {
+		trimmed := strings.TrimPrefix(strings.TrimLeft(name, "_"), separator)
+		if trimmed == name {
+			break
+		}
+		name = trimmed
+	}
+	// The number segments are array indexes, e.g. 'SERVERS__1__PORT' is converted to 'servers[1].port'.
+	sps := strings.Split(strings.ToLower(name), strings.ToLower(separator))
+	var sb strings.Builder
+	for i, sp := range sps {
+		if indexed && i > 0 && len(sp) > 0 && indexAt("["+sp+"]", 0) >= 0 {
+			sb.WriteString("[" + sp + "]")
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(sp)
+	}
+	return sb.String()
+}
@@ -62,3 +62,78 @@ func TestEnvParser(t *testing.T) {
 		}
 	}
 }
+
+func TestEnvParserPrefix(t *testing.T) {
+	for i, test := range []struct {
+		prefix    string
+		separator string
+		envs      []string
+		expect    []KV
+	}{
+		{"MYAPP_", "", []string{
+			"PATH=/usr/bin",
+			"MYAPP_FOO_NAME=foo1",
+			"myapp_foo_id=1",
+			"MYAPP_SERVERS_1_HOST=s1",
+			"MYAPPX=x",
+		}, []KV{
+			{"foo.name", "foo1"},
+			{"foo.id", uint64(1)},
+			{"servers.1.host", "s1"},
+		}},
+		{"MYAPP", "__", []string{
+			"PATH=/usr/bin",
+			"MYAPP__FOO__MAX_CONNS=10",
+			"MYAPP__SERVERS__1__HOST_NAME=s1",
+		}, []KV{
+			{"foo.max_conns", uint64(10)},
+			{"servers[1].host_name", "s1"},
+		}},
+		{"", "__", []string{
+			"HOME=/root",
+			"FOO__MAX_CONNS=10",
+			"_P9K_TTY=/dev/ttys002",
+		}, []KV{
+			{"home", "/root"},
+			{"foo.max_conns", uint64(10)},
+			{"p9k_tty", "/dev/ttys002"},
+		}},
+	} {
+		psr := &envParser{prefix: test.prefix, separator: test.separator}
+		psr.parse(test.envs)
+		if !reflect.DeepEqual(psr.kvs, test.expect) {
+			t.Errorf("[%v] got(%v)!=expect(%v)\n", i, psr.kvs, test.expect)
+		}
+	}
+}
+
+func TestEnvNumberSegments(t *testing.T) {
+	c := New()
+	_, err := c.LoadEnvs([]string{
+		"JAVA_HOME_8=/opt/j8",
+		"REDIS_PORT_6379_TCP_ADDR=10.0.0.1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.LoadPrefixedEnvs([]string{"FOO__SERVERS__1__PORT=9000"}, "", "__")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"java.home.8", "/opt/j8"},
+		{"redis.port.6379.tcp.addr", "10.0.0.1"},
+		{"foo.servers[1].port", uint64(9000)},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+	if _, ok := c.Get("redis.port").v.([]any); ok {
+		t.Errorf("got(%v) is array\n", c.Get("redis.port").v)
+	}
+}
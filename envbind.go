@@ -0,0 +1,82 @@
+package olayc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// envBinding binds a key to environment variables, the first present variable wins.
+type envBinding struct {
+	key   string
+	names []string
+}
+
+// String returns the binding as 'foo.redis.host <- REDIS_HOST, FOO_REDIS_HOST'.
+func (b envBinding) String() string {
+	return fmt.Sprintf("%v <- %v", b.key, strings.Join(b.names, ", "))
+}
+
+// BindEnv binds the key to the environment variables, the first present variable wins, e.g.
+//
+//	c.BindEnv("foo.redis.host", "REDIS_HOST", "FOO_REDIS_HOST")
+//
+// Binding the same key again replaces the variables. The bindings are loaded by `LoadEnvBindings()`,
+// they're loaded as an environment layer by `Load()` even if the environments are not turned on.
+func (c *OlayConfig) BindEnv(key string, envNames ...string) {
+	b := envBinding{key, envNames}
+	for i := range c.envBindings {
+		if c.envBindings[i].key == key {
+			c.envBindings[i] = b
+			return
+		}
+	}
+	c.envBindings = append(c.envBindings, b)
+}
+
+// Load the bound environment variables from process environments. Return numbers of kvs loaded.
+func (c *OlayConfig) LoadEnvBindings() (int, error) {
+	src := newEnvBindingsSource(c.envBindings, os.LookupEnv)
+	err := c.load(src)
+	if err != nil {
+		return 0, errors.Wrap(err, "LoadEnvBindings error")
+	}
+	return len(src.kvs), nil
+}
+
+// EnvBindingsSource returns a Source loading the bound environment variables of c, it has the same priority as environments.
+// The variables are looked up when it's created.
+func (c *OlayConfig) EnvBindingsSource() Source {
+	return newEnvBindingsSource(c.envBindings, os.LookupEnv)
+}
+
+// Return the source of bound environment variables looked up by lookup.
+// The values are interpreted as same as environments.
+func newEnvBindingsSource(bindings []envBinding, lookup func(string) (string, bool)) *kvsSource {
+	var kvs []KV
+	for _, b := range bindings {
+		for _, name := range b.names {
+			if v, ok := lookup(name); ok {
+				kvs = append(kvs, KV{b.key, interpret(v)})
+				break
+			}
+		}
+	}
+	return &kvsSource{envBindingsName, PriorityEnv, kvs}
+}
+
+// envBindingsName is the source name of bound environment variables.
+const envBindingsName = "env-bindings"
+
+// Print the environment bindings for usage message.
+func usageEnvBindings(bindings []envBinding) {
+	if len(bindings) == 0 {
+		return
+	}
+	fmt.Println("Environment bindings:")
+	for _, b := range bindings {
+		fmt.Printf("  %v\n", b)
+	}
+}
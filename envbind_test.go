@@ -0,0 +1,64 @@
+package olayc
+
+import (
+	"testing"
+)
+
+func TestEnvBindings(t *testing.T) {
+	envs := map[string]string{
+		"REDIS_HOST":     "redis.env",
+		"FOO_REDIS_HOST": "redis.foo",
+		"FOO_REDIS_PORT": "6380",
+		"FOO_NAME":       "foo-env",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := envs[name]
+		return v, ok
+	}
+
+	c := New()
+	c.BindEnv("foo.redis.host", "REDIS_HOST", "FOO_REDIS_HOST")
+	c.BindEnv("foo.redis.port", "REDIS_PORT", "FOO_REDIS_PORT")
+	c.BindEnv("foo.id", "FOO_ID")
+	c.BindEnv("foo.url", "URL")
+	// Binding again replaces the variables.
+	c.BindEnv("foo.url", "FOO_NAME")
+	if len(c.envBindings) != 4 {
+		t.Fatalf("bindings got(%v)\n", c.envBindings)
+	}
+
+	c.AddSource(newEnvBindingsSource(c.envBindings, lookup))
+	c.AddSource(YamlSource([]byte(`
+foo:
+  id: 1
+  name: foo1
+  redis:
+    host: localhost
+    port: 6379
+`)))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"foo.redis.host", "redis.env"},
+		{"foo.redis.port", uint64(6380)},
+		{"foo.id", 1},
+		{"foo.name", "foo1"},
+		{"foo.url", "foo-env"},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+	if got := c.Origin("foo.redis.host").String(); got != envBindingsName {
+		t.Errorf("Origin got(%v)!=expect(%v)\n", got, envBindingsName)
+	}
+	if got := c.envBindings[0].String(); got != "foo.redis.host <- REDIS_HOST, FOO_REDIS_HOST" {
+		t.Errorf("String got(%v)\n", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Package etcdsource provides the olayc Source reading keys from etcd v3.
+// It's a separate package, thus the etcd client is linked only if it's imported.
+package etcdsource
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spgyip/olayc"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Default timeout of reading etcd.
+const defaultTimeout = 10 * time.Second
+
+// Source is an olayc Source reading all keys under a prefix from etcd v3.
+//
+// The keys are converted by trimming the prefix and replacing '/' with '.',
+// e.g. with prefix '/app/', key '/app/foo/redis/host' is converted to 'foo.redis.host'.
+// The prefix is a directory, e.g. '/app' is the same as '/app/', it doesn't match '/apple/x'.
+// Values are interpreted as same as commandline arguments, refer to `olayc.Interpret()`.
+type Source struct {
+	cli    *clientv3.Client
+	prefix string
+}
+
+// New returns a Source reading all keys under the prefix from etcd v3, it has the same priority as files, e.g.
+//
+//	olayc.Load(olayc.WithSource(etcdsource.New(cli, "/app/")))
+func New(cli *clientv3.Client, prefix string) *Source {
+	return &Source{cli, prefix}
+}
+
+func (s *Source) Name() string  { return "etcd:" + s.prefix }
+func (s *Source) Priority() int { return olayc.PriorityFile }
+func (s *Source) Kind() string  { return "etcd" }
+func (s *Source) Load() (map[any]any, error) {
+	kvs, err := s.kvs()
+	if err != nil {
+		return nil, err
+	}
+	return olayc.KVsSource(s.Name(), s.Priority(), kvs).Load()
+}
+
+// Read kvs under the prefix, ordered by key.
+func (s *Source) kvs() ([]olayc.KV, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	prefix := dirPrefix(s.prefix)
+	resp, err := s.cli.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []olayc.KV
+	for _, ekv := range resp.Kvs {
+		key := strings.Trim(strings.TrimPrefix(string(ekv.Key), prefix), "/")
+		if len(key) == 0 {
+			continue
+		}
+		kvs = append(kvs, olayc.NewKV(strings.ReplaceAll(key, "/", "."), olayc.Interpret(string(ekv.Value))))
+	}
+	return kvs, nil
+}
+
+// Return the prefix ending with '/', thus it matches only the keys in the directory. The empty prefix matches all keys.
+func dirPrefix(prefix string) string {
+	if len(prefix) == 0 || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix + "/"
+}
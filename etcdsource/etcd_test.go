@@ -0,0 +1,99 @@
+package etcdsource
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spgyip/olayc"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// Start an embedded etcd server in-process, return a client connected to it.
+func startTestEtcd(t *testing.T) *clientv3.Client {
+	u, _ := url.Parse("http://127.0.0.1:0")
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	cfg.LCUrls = []url.URL{*u}
+	cfg.LPUrls = []url.URL{*u}
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd server is not ready")
+	}
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{e.Clients[0].Addr().String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+func TestLoadEtcd(t *testing.T) {
+	cli := startTestEtcd(t)
+	for k, v := range map[string]string{
+		"/app/foo/name":       "foo-etcd",
+		"/app/foo/id":         "123",
+		"/app/foo/onoff":      "true",
+		"/app/foo/redis/host": "redis.cluster",
+		"/app/foo/redis/port": "6380",
+		"/apple/foo/color":    "red",
+		"/other/foo/name":     "foo-other",
+		"/other/foo/url":      "http://www.example.com",
+		"/otherwise/foo/zone": "sz",
+	} {
+		_, err := cli.Put(context.Background(), k, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var c = olayc.New()
+	// The prefix without trailing '/' doesn't match the sibling keys, e.g. '/apple'.
+	for _, prefix := range []string{"/app", "/other/"} {
+		err := c.LoadSource(New(cli, prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"foo.name", "foo-etcd"},
+		{"foo.id", "123"},
+		{"foo.onoff", "true"},
+		{"foo.redis.host", "redis.cluster"},
+		{"foo.redis.port", "6380"},
+		{"foo.url", "http://www.example.com"},
+		{"foo.color", ""},
+		{"le.foo.color", ""},
+		{"foo.zone", ""},
+		{"wise.foo.zone", ""},
+	} {
+		got := c.Get(test.key)
+		if got.String() != test.expect {
+			t.Errorf("[%v] key=%v, got(%v)!=expect(%v)\n", i, test.key, got.String(), test.expect)
+		}
+	}
+	if got := c.Uint64("foo.redis.port", 0); got != 6380 {
+		t.Errorf("Interpret got(%v)!=expect(6380)\n", got)
+	}
+	if got := c.Origin("foo.name").String(); got != "etcd etcd:/app" {
+		t.Errorf("Origin got(%v)\n", got)
+	}
+}
@@ -17,6 +17,7 @@ type flagParser struct {
 // -key=value | --key=value
 // -key value | --key value
 // -key       | --key         # Same as -key=true, -key true
+// -key=      | --key=        # Null value
 //
 // Value interpretation should refer to `interpreted(string)`.
 func (psr *flagParser) parse(args []string) int {
@@ -69,8 +70,12 @@ func (psr *flagParser) parseOne() (bool, error) {
 		key = key[:pos]
 	}
 
-	// Interpret string to concrete type value
-	value = interpret(strValue)
+	// Interpret string to concrete type value, the empty value after '=' is null, e.g. '-name='.
+	if pos >= 0 && len(strValue) == 0 {
+		value = nil
+	} else {
+		value = interpret(strValue)
+	}
 	psr.kvs = append(psr.kvs, KV{key, value})
 
 	return false, nil
@@ -17,6 +17,7 @@ func TestFlagParser(t *testing.T) {
 		"-foo.on1",         // Bool value, default true
 		"-foo.on2", "true", // Bool value
 		"-foo.on3", "false", // Bool value
+		"-foo.null=", // Null value
 		"invalid-arg",
 	})
 
@@ -31,6 +32,7 @@ func TestFlagParser(t *testing.T) {
 		{"foo.on1", true},
 		{"foo.on2", true},
 		{"foo.on3", false},
+		{"foo.null", nil},
 	}
 
 	if len(expect) != len(got) {
@@ -0,0 +1,199 @@
+package olayc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Default timeout of http source.
+const defaultHttpTimeout = 10 * time.Second
+
+// mediaFormats are the file formats keyed by media type of Content-Type.
+var mediaFormats = map[string]string{
+	"application/yaml":       "yaml",
+	"application/x-yaml":     "yaml",
+	"text/yaml":              "yaml",
+	"text/x-yaml":            "yaml",
+	"application/json":       "json",
+	"text/json":              "json",
+	"application/toml":       "toml",
+	"text/toml":              "toml",
+	"text/x-java-properties": "properties",
+}
+
+// httpCache is the cached response of http source.
+type httpCache struct {
+	Url    string `json:"url"`
+	ETag   string `json:"etag"`
+	Format string `json:"format"`
+	Body   []byte `json:"body"`
+}
+
+// httpSource is a Source fetching yaml/json/toml from url.
+//
+// The format is detected from the Content-Type header, or the extension of url path if Content-Type is not specific.
+// The response is cached in memory and on disk(if cacheDir is not empty), and revalidated with 'ETag'/'If-None-Match'.
+// If the server is unreachable or responds 5xx, the cached response is used.
+type httpSource struct {
+	url      string
+	priority int
+	client   *http.Client
+	cacheDir string
+	cache    *httpCache
+}
+
+// HttpSource returns a Source fetching config from url, e.g. 'https://config.example.com/app.yaml'.
+// If timeout is 0, the default timeout 10s is used.
+// If cacheDir is not empty, the response is cached on disk, which is used when the server is unreachable.
+func HttpSource(url string, timeout time.Duration, cacheDir string) Source {
+	if timeout == 0 {
+		timeout = defaultHttpTimeout
+	}
+	return &httpSource{
+		url:      url,
+		priority: PriorityFile,
+		client:   &http.Client{Timeout: timeout},
+		cacheDir: cacheDir,
+	}
+}
+
+func (s *httpSource) Name() string  { return s.url }
+func (s *httpSource) Priority() int { return s.priority }
+func (s *httpSource) Load() (map[any]any, error) {
+	cache, fresh, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	m, err := decode(cache.Format, cache.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Only the valid response is cached.
+	if fresh {
+		s.saveCache(cache)
+	}
+	return m, nil
+}
+
+// Fetch from url, return the cached response if not modified or the server is unreachable.
+// Return fresh true if it's a new response.
+func (s *httpSource) fetch() (*httpCache, bool, error) {
+	cached := s.loadCache()
+
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if cached != nil && len(cached.ETag) > 0 {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if cached != nil {
+			return cached, false, nil
+		}
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, false, nil
+	case resp.StatusCode >= 500 && cached != nil:
+		return cached, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, errors.Errorf("unexpected status: %v", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if cached != nil {
+			return cached, false, nil
+		}
+		return nil, false, err
+	}
+	format := s.formatOf(resp)
+	if len(format) == 0 {
+		return nil, false, errors.Errorf("unknown format, Content-Type: %v", resp.Header.Get("Content-Type"))
+	}
+
+	return &httpCache{s.url, resp.Header.Get("ETag"), format, body}, true, nil
+}
+
+// Return format from Content-Type, or the extension of url path.
+func (s *httpSource) formatOf(resp *http.Response) string {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err == nil {
+		if format, ok := mediaFormats[mediaType]; ok {
+			return format
+		}
+	}
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return ""
+	}
+	return formatOf(u.Path)
+}
+
+// Return the disk cache file of url.
+func (s *httpSource) cacheFile() string {
+	sum := sha256.Sum256([]byte(s.url))
+	return filepath.Join(s.cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load cache from memory, or from disk if it's not in memory. Return nil if there's no cache.
+func (s *httpSource) loadCache() *httpCache {
+	if s.cache != nil || len(s.cacheDir) == 0 {
+		return s.cache
+	}
+	data, err := os.ReadFile(s.cacheFile())
+	if err != nil {
+		return nil
+	}
+	var cache httpCache
+	if err = json.Unmarshal(data, &cache); err != nil || cache.Url != s.url {
+		return nil
+	}
+	s.cache = &cache
+	return s.cache
+}
+
+// Save cache to memory and disk. Errors of saving to disk are ignored, the cache is just for fallback.
+func (s *httpSource) saveCache(cache *httpCache) {
+	s.cache = cache
+	if len(s.cacheDir) == 0 {
+		return
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(s.cacheDir, 0700); err != nil {
+		return
+	}
+	tmp := s.cacheFile() + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return
+	}
+	os.Rename(tmp, s.cacheFile())
+}
+
+// Return the default disk cache directory of http sources, return "" if it's unavailable.
+func defaultHttpCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "olayc")
+}
@@ -0,0 +1,104 @@
+package olayc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpSource(t *testing.T) {
+	var requests, notModified int
+	var down bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/app.yaml":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("foo: {name: foo-http}"))
+		case "/app":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"foo": {"id": 1}}`))
+		case "/unknown":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("foo"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+
+	// Format from extension, the response is cached.
+	var c = New()
+	err := c.LoadSource(HttpSource(srv.URL+"/app.yaml", 0, cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-http" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-http")
+	}
+
+	// Revalidated with ETag by a new source, the disk cache is used.
+	c = New()
+	err = c.LoadSource(HttpSource(srv.URL+"/app.yaml", 0, cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notModified != 1 {
+		t.Errorf("got(%v)!=expect(%v) not modified responses\n", notModified, 1)
+	}
+	if got := c.String("foo.name", ""); got != "foo-http" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-http")
+	}
+
+	// Format from Content-Type.
+	err = c.LoadSource(HttpSource(srv.URL+"/app", 0, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Int("foo.id", 0); got != 1 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 1)
+	}
+
+	for i, u := range []string{srv.URL + "/unknown", srv.URL + "/not-found.yaml"} {
+		if err = New().LoadSource(HttpSource(u, 0, cacheDir)); err == nil {
+			t.Errorf("[%v] expect error loading %v", i, u)
+		}
+	}
+
+	// Fallback to disk cache if the server is down or unreachable.
+	down = true
+	c = New()
+	err = c.LoadSource(HttpSource(srv.URL+"/app.yaml", 0, cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-http" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-http")
+	}
+	if err = New().LoadSource(HttpSource(srv.URL+"/app", 0, cacheDir)); err == nil {
+		t.Error("expect error if the server is down without cache")
+	}
+
+	url := srv.URL + "/app.yaml"
+	srv.Close()
+	c = New()
+	err = c.LoadSource(HttpSource(url, 0, cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-http" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-http")
+	}
+}
@@ -0,0 +1,164 @@
+package olayc
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IncludeKey is the top-level key of include directive in config files, e.g.
+//
+//	$include: [common.yaml, redis.yaml]
+//	foo:
+//	  name: foo1
+//
+// The value is a file path or a list of file paths, relative paths are resolved relative to the including file.
+// The included files are overlayed beneath the including file's own keys, and the latter included file wins.
+// Included files can include other files recursively, include cycles are reported as errors.
+const IncludeKey = "$include"
+
+// includer loads files and resolves include directives.
+// The files are read from fsys, or the OS file system if fsys is nil.
+type includer struct {
+	fsys  fs.FS
+	stack []string
+}
+
+// includedFile is a decoded file with include directives resolved.
+type includedFile struct {
+	m map[any]any
+	// lines are the line numbers of the dotted keys, including the keys of included files, if the formats support it.
+	lines map[string]int
+	// files are the names of included files of the dotted keys, the other keys are from the file itself.
+	files map[string]string
+}
+
+// Read file and resolve include directives.
+func (inc *includer) load(name string, format string) (*includedFile, error) {
+	var data []byte
+	var err error
+	if inc.fsys == nil {
+		data, err = os.ReadFile(name)
+	} else {
+		data, err = fs.ReadFile(inc.fsys, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := decode(format, data)
+	if err != nil {
+		return nil, err
+	}
+	f := &includedFile{m: m, lines: decodeLines(format, data)}
+	err = inc.include(name, format, f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Resolve include directives of f, which is decoded from file name.
+// The included files without known extension are decoded with the same format as the including file.
+func (inc *includer) include(name string, format string, f *includedFile) error {
+	id := name
+	if inc.fsys == nil {
+		if abs, err := filepath.Abs(name); err == nil {
+			id = abs
+		}
+	}
+	for _, s := range inc.stack {
+		if s == id {
+			return errors.Errorf("include cycle: %v -> %v", strings.Join(inc.stack, " -> "), id)
+		}
+	}
+
+	includes, err := includesOf(f.m)
+	if err != nil {
+		return errors.Wrapf(err, "file %v", name)
+	}
+	if len(includes) == 0 {
+		return nil
+	}
+
+	inc.stack = append(inc.stack, id)
+	defer func() { inc.stack = inc.stack[:len(inc.stack)-1] }()
+
+	// The latter included file wins, thus copy in reverse order.
+	for i := len(includes) - 1; i >= 0; i-- {
+		includeName := inc.resolve(name, includes[i])
+		includeFormat := formatOf(includeName)
+		if len(includeFormat) == 0 {
+			includeFormat = format
+		}
+		sub, err := inc.load(includeName, includeFormat)
+		if err != nil {
+			return errors.Wrapf(err, "include %v", includeName)
+		}
+		cp := &mapCopier{
+			onCopy: func(key string, v any) {
+				walkLeaves(key, v, func(key string, _ any) {
+					f.addKey(key, sub, includeName)
+				})
+			},
+		}
+		cp.copy(f.m, sub.m, "")
+	}
+	return nil
+}
+
+// Record the line number and the file name of the key copied from the included file sub, which is named name.
+func (f *includedFile) addKey(key string, sub *includedFile, name string) {
+	if line, ok := sub.lines[key]; ok {
+		if f.lines == nil {
+			f.lines = make(map[string]int)
+		}
+		f.lines[key] = line
+	}
+	if n, ok := sub.files[key]; ok {
+		name = n
+	}
+	if f.files == nil {
+		f.files = make(map[string]string)
+	}
+	f.files[key] = name
+}
+
+// Resolve included path relative to the including file.
+func (inc *includer) resolve(name string, include string) string {
+	if inc.fsys == nil {
+		if filepath.IsAbs(include) {
+			return include
+		}
+		return filepath.Join(filepath.Dir(name), include)
+	}
+	return path.Join(path.Dir(name), include)
+}
+
+// Return included paths and delete the include directive from m.
+func includesOf(m map[any]any) ([]string, error) {
+	v, ok := m[IncludeKey]
+	if !ok {
+		return nil, nil
+	}
+	delete(m, IncludeKey)
+
+	switch x := v.(type) {
+	case string:
+		return []string{x}, nil
+	case []any:
+		var includes []string
+		for _, sub := range x {
+			s, ok := sub.(string)
+			if !ok {
+				return nil, errors.Errorf("invalid %v value: %v", IncludeKey, sub)
+			}
+			includes = append(includes, s)
+		}
+		return includes, nil
+	}
+	return nil, errors.Errorf("invalid %v value: %v", IncludeKey, v)
+}
@@ -0,0 +1,143 @@
+package olayc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestConfigLoadYamlFileInclude(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"app.yaml": `
+$include: [common/common.yaml, redis.json]
+foo:
+  name: foo-app
+`,
+		"common/common.yaml": `
+$include: base.yaml
+foo:
+  name: foo-common
+  id: 1
+  redis:
+    host: redis.common
+`,
+		"common/base.yaml": `
+foo:
+  id: 0
+  url: http://www.base.com
+`,
+		"redis.json": `{"foo": {"redis": {"host": "redis.json", "port": 6380}}}`,
+	})
+
+	var c = New()
+	err := c.LoadYamlFile(filepath.Join(dir, "app.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"foo.name", "foo-app"},
+		{"foo.id", 1},
+		{"foo.url", "http://www.base.com"},
+		{"foo.redis.host", "redis.json"},
+		{"foo.redis.port", 6380},
+		{IncludeKey, nil},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key=%v, got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+}
+
+func TestConfigLoadJsonFileIncludeCycle(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"a.json":     `{"$include": "sub/b.json", "foo": {"name": "a"}}`,
+		"sub/b.json": `{"$include": ["../a.json"]}`,
+	})
+
+	var c = New()
+	err := c.LoadJsonFile(filepath.Join(dir, "a.json"))
+	if err == nil {
+		t.Fatal("expect include cycle error")
+	}
+	if !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expect include cycle error, got: %v", err)
+	}
+}
+
+func TestConfigLoadYamlFileIncludeError(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"not-exist.yaml": "$include: not-exist-include.yaml",
+		"invalid.yaml":   "$include: {foo: bar}",
+	})
+
+	var c = New()
+	for i, name := range []string{"not-exist.yaml", "invalid.yaml"} {
+		err := c.LoadYamlFile(filepath.Join(dir, name))
+		if err == nil {
+			t.Errorf("[%v] expect error loading %v", i, name)
+		}
+	}
+}
+
+func TestConfigLoadFSInclude(t *testing.T) {
+	var fsys = fstest.MapFS{
+		"conf/app.yaml":    {Data: []byte("$include: common.toml\nfoo: {name: foo-app}")},
+		"conf/common.toml": {Data: []byte("[foo]\nname = \"foo-common\"\nid = 1")},
+	}
+
+	var c = New()
+	err := c.LoadFS(fsys, "conf/app.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-app" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-app")
+	}
+	if got := c.Int("foo.id", 0); got != 1 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 1)
+	}
+}
+
+func TestIncludeOrigin(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"main.yaml": `
+$include: inc.yaml
+db:
+  name: main
+`,
+		"inc.yaml": `
+$include: redis.ini
+db:
+  name: inc
+  url: ${nothing}
+`,
+		"redis.ini": "[redis]\nhost = redis.ini\n",
+	})
+
+	c := New()
+	c.AddSource(YamlFileSource(filepath.Join(dir, "main.yaml")))
+	err := c.LoadSources()
+	expect := "interpolate db.url (file " + filepath.Join(dir, "inc.yaml") + ":5): reference '${nothing}' not found"
+	if err == nil || err.Error() != expect {
+		t.Errorf("got(%v)!=expect(%v)\n", err, expect)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"db.name", "file " + filepath.Join(dir, "main.yaml") + ":4"},
+		{"db.url", "file " + filepath.Join(dir, "inc.yaml") + ":5"},
+		{"redis.host", "file " + filepath.Join(dir, "redis.ini") + ":2"},
+	} {
+		if got := c.Origin(test.key).String(); got != test.expect {
+			t.Errorf("[%v] key=%v, got(%v)!=expect(%v)\n", i, test.key, got, test.expect)
+		}
+	}
+}
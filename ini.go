@@ -0,0 +1,112 @@
+package olayc
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// iniParser parses ini file to kvs.
+// The supported syntax:
+//
+//	; Comment line
+//	# Comment line
+//	name = foo          ; Keys before any section are in the root
+//	[foo]
+//	id = 123            ; => foo.id
+//	url: http://a.b     ; ':' is also accepted as seperator
+//	[foo.redis]
+//	host = "localhost"  ; => foo.redis.host, quoted value is kept as string
+//
+// The section names and keys are splitted by '.' to sub-trees.
+// If a key is defined more than once, the last one wins.
+//
+// Value interpretation should refer to `interpret(string)`, quoted values are not interpreted.
+type iniParser struct {
+	kvs   []KV
+	index map[string]int
+	lines map[string]int
+}
+
+// Parse ini bytes, return error with line number if syntax error.
+func (psr *iniParser) parse(data []byte) error {
+	psr.index = make(map[string]int)
+	psr.lines = make(map[string]int)
+	var section string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		// Section header
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return errors.Errorf("line %v: unterminated section header", i+1)
+			}
+			section = strings.TrimSpace(line[1:end])
+			if len(section) == 0 {
+				return errors.Errorf("line %v: empty section name", i+1)
+			}
+			continue
+		}
+
+		pos := strings.IndexAny(line, "=:")
+		if pos <= 0 {
+			return errors.Errorf("line %v: invalid line \"%v\"", i+1, line)
+		}
+		key := strings.TrimSpace(line[:pos])
+		if len(section) > 0 {
+			key = section + "." + key
+		}
+		psr.add(key, iniValue(strings.TrimSpace(line[pos+1:])))
+		psr.lines[key] = i + 1
+	}
+	return nil
+}
+
+// Add kv, replace the value if key exists.
+func (psr *iniParser) add(key string, value any) {
+	if i, ok := psr.index[key]; ok {
+		psr.kvs[i].value = value
+		return
+	}
+	psr.index[key] = len(psr.kvs)
+	psr.kvs = append(psr.kvs, KV{key, value})
+}
+
+// Return value of raw ini value string.
+// Quoted value is unquoted and kept as string, otherwise inline comment is trimmed and the value is interpreted.
+func iniValue(s string) any {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.IndexByte(s[1:], s[0]); end >= 0 {
+			return s[1 : end+1]
+		}
+	}
+	for _, sep := range []string{" ;", " #", "\t;", "\t#"} {
+		if pos := strings.Index(s, sep); pos >= 0 {
+			s = strings.TrimSpace(s[:pos])
+		}
+	}
+	return interpret(s)
+}
+
+// Decode ini bytes.
+func decodeIni(data []byte) (map[any]any, error) {
+	psr := &iniParser{}
+	err := psr.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return kvsToMap(psr.kvs), nil
+}
+
+// Return line numbers of ini keys, return nil if syntax error.
+func iniLines(data []byte) map[string]int {
+	psr := &iniParser{}
+	if psr.parse(data) != nil {
+		return nil
+	}
+	return psr.lines
+}
@@ -0,0 +1,87 @@
+package olayc
+
+import (
+	"testing"
+)
+
+func TestIniParser(t *testing.T) {
+	var testdata = []byte(`
+; Comment line
+# Comment line
+name = root
+
+[foo]
+name = foo1
+id = 123
+pi: 3.1415926
+onoff = true ; Inline comment
+quoted = "123"
+name = foo2
+
+[foo.redis]
+host = localhost
+port = 6380
+`)
+
+	psr := &iniParser{}
+	err := psr.parse(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got = psr.kvs
+	var expect = []KV{
+		{"name", "root"},
+		{"foo.name", "foo2"},
+		{"foo.id", uint64(123)},
+		{"foo.pi", float64(3.1415926)},
+		{"foo.onoff", true},
+		{"foo.quoted", "123"},
+		{"foo.redis.host", "localhost"},
+		{"foo.redis.port", uint64(6380)},
+	}
+	if len(expect) != len(got) {
+		t.Logf("expect(len %v) %v\n", len(expect), expect)
+		t.Logf("got(len %v) %v\n", len(got), got)
+		t.FailNow()
+	}
+	for i := range expect {
+		if expect[i] != got[i] {
+			t.Errorf("[%v] expect(%v) != got(%v)", i, expect[i], got[i])
+		}
+	}
+}
+
+func TestIniParserError(t *testing.T) {
+	for i, test := range []string{
+		"[foo",
+		"[]",
+		"foo",
+		"= foo",
+	} {
+		psr := &iniParser{}
+		err := psr.parse([]byte(test))
+		if err == nil {
+			t.Errorf("[%v] expect error parsing %q", i, test)
+		}
+	}
+}
+
+func TestConfigGetScalarLoadIni(t *testing.T) {
+	var c = New()
+	err := c.LoadIni([]byte(`
+[foo]
+name = foo1
+[foo.redis]
+port = 6380
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo1" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo1")
+	}
+	if got := c.Int("foo.redis.port", 0); got != 6380 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 6380)
+	}
+}
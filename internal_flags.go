@@ -49,25 +49,119 @@ var internalFlags = map[string]internalFlag{
 		reflect.String,
 		"Load json file.",
 	},
+	"file.toml": internalFlag{
+		"oc.file.toml",
+		"oc.f.t",
+		reflect.String,
+		"Load toml file.",
+	},
+	"file.ini": internalFlag{
+		"oc.file.ini",
+		"oc.f.ini",
+		reflect.String,
+		"Load ini file.",
+	},
+	"file.properties": internalFlag{
+		"oc.file.properties",
+		"oc.f.prop",
+		reflect.String,
+		"Load java .properties file.",
+	},
+	"file.env": internalFlag{
+		"oc.file.env",
+		"oc.f.env",
+		reflect.String,
+		"Load dotenv(.env) file.",
+	},
+	"dir": internalFlag{
+		"oc.dir",
+		"oc.d",
+		reflect.String,
+		"Load config files in directory, the latter file in lexical order wins.",
+	},
+	"kdir": internalFlag{
+		"oc.kdir",
+		"oc.kd",
+		reflect.String,
+		"Load key-per-file directory, e.g. kubernetes ConfigMap volume.",
+	},
+	"url": internalFlag{
+		"oc.url",
+		"oc.u",
+		reflect.String,
+		"Load yaml/json/toml from http(s) url, the response is cached on disk for fallback.",
+	},
+	"profile": internalFlag{
+		"oc.profile",
+		"oc.p",
+		reflect.String,
+		"Set profile, e.g. 'prod' loads 'app.prod.yaml' above 'app.yaml'. Also set by env OLAYC_PROFILE.",
+	},
 	"env": internalFlag{
 		"oc.env",
 		"oc.e",
 		reflect.Bool,
 		"Load environment variables.",
 	},
+	"env.prefix": internalFlag{
+		"oc.env.prefix",
+		"oc.ep",
+		reflect.String,
+		"Load environment variables with the prefix, e.g. 'MYAPP_' loads 'MYAPP_FOO_NAME' as 'foo.name'.",
+	},
+	"env.separator": internalFlag{
+		"oc.env.separator",
+		"oc.es",
+		reflect.String,
+		"Set the nesting separator of environment variables, e.g. '__' loads 'FOO__MAX_CONNS' as 'foo.max_conns'. Default is '_'.",
+	},
 	"dryrun": internalFlag{
 		"oc.dryrun",
 		"oc.dr",
 		reflect.Bool,
 		"Dry run, load and print Yaml then exit.",
 	},
+	"unset": internalFlag{
+		"oc.unset",
+		"oc.rm",
+		reflect.String,
+		"Unset key, e.g. 'foo.redis' deletes the key from all sources. It can be used multiple times.",
+	},
+	"explain": internalFlag{
+		"oc.explain",
+		"oc.x",
+		reflect.Bool,
+		"Explain mode, load and print every key with its origin then exit.",
+	},
+}
+
+// Return the internal flag of key.
+func internalFlagOf(key string) (internalFlag, bool) {
+	for _, fl := range internalFlags {
+		if fl.is(key) {
+			return fl, true
+		}
+	}
+	return internalFlag{}, false
+}
+
+// Return the zero value of the flag kind, e.g. the value of empty flag '-oc.f.y='.
+func (fl internalFlag) zero() any {
+	switch fl.knd {
+	case reflect.Bool:
+		return false
+	case reflect.String:
+		return ""
+	}
+	return nil
 }
 
 // Print OlayConfig usage message.
-func usageOlayc() {
+func usageOlayc(bindings []envBinding) {
 	fmt.Println("Usage of olayc:")
 	for _, fn := range internalFlags {
 		fmt.Printf("  -%v|-%v %v\n", fn.full, fn.short, fn.knd)
 		fmt.Printf("         %v\n", fn.help)
 	}
+	usageEnvBindings(bindings)
 }
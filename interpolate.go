@@ -0,0 +1,272 @@
+package olayc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Interpolation syntax in string values.
+const (
+	placeholderBegin = "${"
+	placeholderEnd   = "}"
+	// The fallback seperator, e.g. '${foo.port:-80}'.
+	placeholderFallback = ":-"
+	// The escaped placeholder, e.g. '$${foo}' is the literal '${foo}'.
+	placeholderEscape = "$${"
+)
+
+// interpolator resolves the placeholders in string values of the configure tree, e.g.
+//
+//	redis:
+//	  host: redis.cluster
+//	  url: redis://${redis.host}:${redis.port:-6379}
+//
+// The resolved values are cached by key paths, the keys being resolved are tracked for detecting cycles.
+type interpolator struct {
+	c         *OlayConfig
+	root      map[any]any
+	done      map[string]any
+	failed    map[string]error
+	resolving map[string]bool
+	stack     []string
+	errs      []error
+	schemes   map[string]schemeResult
+}
+
+// literalKinds are the origin kinds whose values are literal, the placeholders in them are not resolved,
+// e.g. 'PS1=${debian_chroot:+($debian_chroot)}' in process environments.
+var literalKinds = map[string]bool{
+	"args":          true,
+	"envs":          true,
+	envBindingsName: true,
+}
+
+// Resolve the placeholders '${other.key}' in string values of the merged configure,
+// and the placeholders with registered scheme, e.g. '${env:DB_PASSWORD}', refer to `Resolver`.
+// The placeholders are resolved after all sources are merged, thus the overrides propagate into the derived values.
+// It's called by `Load()` and `LoadSources()` after the sources are loaded, and it's called on demand by `Get()`
+// if any source is loaded or any resolver is registered after last resolving. The resolvers are called again by every resolving.
+//
+// The values from commandline arguments, environments and dotenv files are literal, they can be referenced but they're not resolved.
+//
+// If the string value is exactly one placeholder, the value is the referenced value with its type, e.g. int or sub-tree,
+// otherwise the referenced values are formatted into the string.
+// The fallback is used if the referenced key is missing or null, e.g. '${foo.port:-80}'. '$${' is the literal '${'.
+//
+// It returns the first error of unresolved placeholders, e.g. the missing references and the reference cycles,
+// the error is `*ResolveError` with the key path and the origin.
+// The values failed to resolve are kept as they are.
+func (c *OlayConfig) Resolve() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolve()
+	return c.resolveErr
+}
+
+// Return the merged configure with resolved placeholders, it's resolved again if it's stale.
+// It's guarded by the mutex, thus `Get()` is safe for concurrent use.
+func (c *OlayConfig) resolvedTree() map[any]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resolved == nil {
+		c.resolve()
+	}
+	return c.resolved
+}
+
+// Mark the resolved configure stale, it's resolved again on demand.
+func (c *OlayConfig) staleResolved() {
+	c.mu.Lock()
+	c.resolved = nil
+	c.mu.Unlock()
+}
+
+// Resolve the placeholders of the merged configure, the caller must hold the mutex.
+func (c *OlayConfig) resolve() {
+	ip := &interpolator{
+		c:         c,
+		root:      prune(c.merged).(map[any]any),
+		done:      make(map[string]any),
+		failed:    make(map[string]error),
+		resolving: make(map[string]bool),
+		schemes:   make(map[string]schemeResult),
+	}
+	v, _, _ := ip.resolveKey(Root)
+	c.resolved = v.(map[any]any)
+	c.resolveErr = nil
+	if len(ip.errs) > 0 {
+		c.resolveErr = ip.errs[0]
+	}
+}
+
+// Resolve the value of key path, return false if it doesn't exist.
+// The error is returned if the value or its reference fails to resolve, and the value is kept as it is.
+func (ip *interpolator) resolveKey(key string) (any, bool, error) {
+	if v, ok := ip.done[key]; ok {
+		return v, true, ip.failed[key]
+	}
+	v, ok := lookup(ip.root, key)
+	if !ok {
+		return nil, false, nil
+	}
+	if ip.resolving[key] {
+		return v, true, errors.Errorf("reference cycle %v", ip.cycle(key))
+	}
+
+	ip.resolving[key] = true
+	ip.stack = append(ip.stack, key)
+	v, err := ip.resolveValue(key, v)
+	ip.stack = ip.stack[:len(ip.stack)-1]
+	delete(ip.resolving, key)
+	ip.done[key] = v
+	if err != nil {
+		ip.failed[key] = err
+	}
+	return v, true, err
+}
+
+// Resolve the value of key path, the sub-trees are resolved recursively.
+// The errors of string values are recorded, the sub-trees don't fail even if some values of them fail.
+func (ip *interpolator) resolveValue(key string, v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		if literalKinds[ip.c.Origin(key).Kind] {
+			return x, nil
+		}
+		rv, err := ip.resolveString(x)
+		if err != nil {
+			ip.errs = append(ip.errs, &ResolveError{key, ip.c.Origin(key), err})
+			return x, err
+		}
+		return rv, nil
+	case map[any]any:
+		// Sorted for the deterministic first error.
+		keys := make([]any, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		m := make(map[any]any, len(x))
+		for _, k := range keys {
+			m[k], _, _ = ip.resolveKey(joinKey(key, k))
+		}
+		return m, nil
+	case []any:
+		sl := make([]any, len(x))
+		for i := range x {
+			sl[i], _, _ = ip.resolveKey(fmt.Sprintf("%v[%v]", key, i))
+		}
+		return sl, nil
+	}
+	return v, nil
+}
+
+// Return the reference cycle ending with key, e.g. 'a -> b -> a'.
+func (ip *interpolator) cycle(key string) string {
+	i := len(ip.stack) - 1
+	for i > 0 && ip.stack[i] != key {
+		i--
+	}
+	return strings.Join(append(append([]string{}, ip.stack[i:]...), key), " -> ")
+}
+
+// Resolve the placeholders in s. If s is exactly one placeholder, the referenced value is returned with its type.
+func (ip *interpolator) resolveString(s string) (any, error) {
+	if !strings.Contains(s, placeholderBegin) {
+		return s, nil
+	}
+	if begin, end := placeholderAt(s, 0); begin == 0 && end == len(s) {
+		return ip.resolvePlaceholder(s[len(placeholderBegin) : end-len(placeholderEnd)])
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], placeholderEscape) {
+			sb.WriteString(placeholderBegin)
+			i += len(placeholderEscape)
+			continue
+		}
+		begin, end := placeholderAt(s, i)
+		if begin != i {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		v, err := ip.resolvePlaceholder(s[begin+len(placeholderBegin) : end-len(placeholderEnd)])
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			sb.WriteString(fmt.Sprint(v))
+		}
+		i = end
+	}
+	return sb.String(), nil
+}
+
+// Resolve the placeholder expression, e.g. 'foo.port:-80'.
+func (ip *interpolator) resolvePlaceholder(expr string) (any, error) {
+	ref, fallback, hasFallback := strings.Cut(expr, placeholderFallback)
+	if len(ref) == 0 {
+		return nil, errors.Errorf("empty reference '${%v}'", expr)
+	}
+	var v any
+	var ok bool
+	var err error
+	if r, rest := ip.c.resolverOf(ref); r != nil {
+		v, ok, err = ip.resolveScheme(r, rest, ref)
+	} else {
+		v, ok, err = ip.resolveKey(canonicalKey(ip.c.keyNorm.path(ref)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ok && v != nil {
+		return cloneValue(v), nil
+	}
+	if hasFallback {
+		return ip.resolveString(fallback)
+	}
+	if ok {
+		return nil, nil
+	}
+	return nil, errors.Errorf("reference '${%v}' not found", ref)
+}
+
+// Return s with the placeholders escaped, thus it's literal in interpolation, e.g. '${foo}' is '$${foo}'.
+func escapePlaceholders(s string) string {
+	return strings.ReplaceAll(s, placeholderBegin, placeholderEscape)
+}
+
+// Return the first placeholder in s[i:], the placeholder is s[begin:end], the braces are nested.
+// Return -1, -1 if there is no placeholder, the escaped placeholders are skipped.
+func placeholderAt(s string, i int) (int, int) {
+	for ; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], placeholderEscape) {
+			i += len(placeholderEscape) - 1
+			continue
+		}
+		if !strings.HasPrefix(s[i:], placeholderBegin) {
+			continue
+		}
+		depth := 0
+		for j := i + len(placeholderBegin); j < len(s); j++ {
+			if strings.HasPrefix(s[j:], placeholderBegin) {
+				depth++
+				j++
+			} else if strings.HasPrefix(s[j:], placeholderEnd) {
+				if depth == 0 {
+					return i, j + len(placeholderEnd)
+				}
+				depth--
+			}
+		}
+		return -1, -1
+	}
+	return -1, -1
+}
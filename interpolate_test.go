@@ -0,0 +1,151 @@
+package olayc
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	c := New()
+	c.AddSource(ArgsSource([]string{"-redis.host=redis.args"}))
+	c.AddSource(YamlSource([]byte(`
+redis:
+  host: localhost
+  port: 6379
+  url: redis://${redis.host}:${redis.port}/${redis.db:-0}
+  addr: ${redis.host}:${redis.port}
+  nothing: null
+cache:
+  port: ${redis.port}
+  redis: ${redis}
+  timeout: ${cache.timeouts[1]}
+  timeouts: [1, "${cache.default:-${redis.port}}"]
+  nothing: ${redis.nothing:-fallback}
+  null: ${redis.nothing}
+literal: $${redis.host} costs $$5
+unterminated: ${redis.host
+`)))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"redis.url", "redis://redis.args:6379/0"},
+		{"redis.addr", "redis.args:6379"},
+		{"cache.port", 6379},
+		{"cache.redis.host", "redis.args"},
+		{"cache.redis.url", "redis://redis.args:6379/0"},
+		{"cache.timeout", 6379},
+		{"cache.nothing", "fallback"},
+		{"cache.null", nil},
+		{"literal", "${redis.host} costs $$5"},
+		{"unterminated", "${redis.host"},
+	} {
+		got := c.Get(test.key)
+		if got.raw() != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+
+	var cache struct {
+		Port     int   `yaml:"port"`
+		Timeouts []int `yaml:"timeouts"`
+	}
+	err = c.Unmarshal("cache", &cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Port != 6379 || !reflect.DeepEqual(cache.Timeouts, []int{1, 6379}) {
+		t.Errorf("Unmarshal got(%+v)\n", cache)
+	}
+
+	// Placeholders are resolved again after more sources are loaded.
+	err = c.LoadYaml([]byte(`redis: {db: 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("redis.url", ""); got != "redis://redis.args:6379/3" {
+		t.Errorf("got(%v)\n", got)
+	}
+}
+
+func TestInterpolateError(t *testing.T) {
+	for i, test := range []struct {
+		yaml   string
+		expect string
+		key    string
+		value  any
+	}{
+		{"a: ${b}\nb: ${c}\nc: ${a}\n", "interpolate c (bytes yaml:3): reference cycle a -> b -> c -> a", "a", "${b}"},
+		{"a: ${a}x\n", "interpolate a (bytes yaml:1): reference cycle a -> a", "a", "${a}x"},
+		{"a:\n  b: ${a}\n", "interpolate a.b (bytes yaml:2): reference cycle a -> a.b -> a", "a.b", "${a}"},
+		{"a: ${b}\nb: 1\nc: ${nothing}\n", "interpolate c (bytes yaml:3): reference '${nothing}' not found", "a", 1},
+		{"a: ${}\n", "interpolate a (bytes yaml:1): empty reference '${}'", "a", "${}"},
+	} {
+		c := New()
+		c.AddSource(YamlSource([]byte(test.yaml)))
+		err := c.LoadSources()
+		if err == nil || err.Error() != test.expect {
+			t.Errorf("[%v] got(%v)!=expect(%v)\n", i, err, test.expect)
+		}
+		if got := c.Get(test.key); got.v != test.value {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.value)
+		}
+	}
+}
+
+func TestInterpolateLiteral(t *testing.T) {
+	ps1 := `${debian_chroot:+($debian_chroot)}\u@\h:\w\$ `
+	c := New()
+	c.AddSource(ArgsSource([]string{"-foo.tmpl=${user}", "-foo.host=foo.args"}))
+	c.AddSource(EnvsSource([]string{"PS1=" + ps1}))
+	c.AddSource(YamlSource([]byte(`
+foo:
+  url: http://${foo.host}/${foo.tmpl}
+`)))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"ps1", ps1},
+		{"foo.tmpl", "${user}"},
+		{"foo.url", "http://foo.args/${user}"},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+}
+
+func TestInterpolateConcurrentGet(t *testing.T) {
+	c := New()
+	err := c.LoadYaml([]byte("redis:\n  host: localhost\n  url: redis://${redis.host}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Readers don't mutate the configure, run with '-race'.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := c.String("redis.url", ""); got != "redis://localhost" {
+				t.Errorf("got(%v)\n", got)
+			}
+			c.Query("**.host")
+		}()
+	}
+	wg.Wait()
+}
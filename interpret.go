@@ -11,6 +11,11 @@ import (
 
 // Interpret type of string s.
 func typeInterpret(s string) reflect.Kind {
+	// The `Stdin` path '-' is a string, e.g. '-oc.f.y=-'.
+	if s == Stdin {
+		return reflect.String
+	}
+
 	var isAllDigit = true
 	var cntDot = 0
 	var isSigned = false
@@ -91,6 +96,11 @@ func interpret(s string) any {
 	return v
 }
 
+// Interpret string to type value as same as commandline arguments, e.g. for custom sources reading string values.
+func Interpret(s string) any {
+	return interpret(s)
+}
+
 // Return if all characters in s are digital('0'-'9')
 func isAllDigit(s string) bool {
 	is := true
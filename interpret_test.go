@@ -18,6 +18,7 @@ func TestTypeInterpret(t *testing.T) {
 		{"True", reflect.Bool},
 		{"false", reflect.Bool},
 		{"False", reflect.Bool},
+		{"-", reflect.String},
 	} {
 		got := typeInterpret(test.s)
 		if got != test.expect {
@@ -47,6 +48,9 @@ func TestInterpret(t *testing.T) {
 		{"True", bool(true)},
 		{"false", bool(false)},
 		{"False", bool(false)},
+
+		{"-", string("-")},
+		{"", nil},
 	} {
 		got := interpret(test.val)
 		if got != test.expect {
@@ -54,3 +58,14 @@ func TestInterpret(t *testing.T) {
 		}
 	}
 }
+
+func TestEmptyValue(t *testing.T) {
+	c := New()
+	_, err := c.LoadEnvs([]string{"FOO_NAME="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", "dflt"); got != "dflt" {
+		t.Errorf("got(%v)!=expect(dflt)\n", got)
+	}
+}
@@ -0,0 +1,72 @@
+package olayc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keyDirSource is a Source loading key-per-file directory,
+// e.g. the kubernetes ConfigMaps and Secrets mounted as volumes.
+//
+// The file names are dotted keys, and the file contents are values, e.g.
+// file '/etc/config/foo.redis.host' with content 'localhost' is converted to 'foo.redis.host=localhost'.
+//
+// The kubelet creates files as symlinks to the timestamped directory through the '..data' symlink:
+//
+//	/etc/config/foo.redis.host -> ..data/foo.redis.host
+//	/etc/config/..data -> ..2023_01_01_00_00_00.123456789
+//	/etc/config/..2023_01_01_00_00_00.123456789/foo.redis.host
+//
+// The entries prefixed with '..' are skipped, symlinks are followed, and sub-directories are skipped.
+type keyDirSource struct {
+	dir      string
+	priority int
+}
+
+func (s *keyDirSource) Name() string  { return s.dir }
+func (s *keyDirSource) Priority() int { return s.priority }
+func (s *keyDirSource) Load() (map[any]any, error) {
+	kvs, err := s.kvs()
+	if err != nil {
+		return nil, err
+	}
+	return kvsToMap(kvs), nil
+}
+
+// Read kvs from the directory in lexical order of file names.
+// The trailing newlines of file contents are trimmed, value interpretation should refer to `interpret(string)`.
+func (s *keyDirSource) kvs() ([]KV, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []KV
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+		path := filepath.Join(s.dir, name)
+		fi, err := os.Stat(path) // Follow symlinks
+		if err != nil {
+			return nil, err
+		}
+		if fi.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, KV{name, interpret(strings.TrimRight(string(data), "\r\n"))})
+	}
+	return kvs, nil
+}
+
+// KeyDirSource returns a Source loading key-per-file directory, e.g. the kubernetes ConfigMaps and Secrets volumes.
+// Refer to `OlayConfig.LoadKeyDir()`.
+func KeyDirSource(dir string) Source {
+	return &keyDirSource{dir, PriorityFile}
+}
@@ -0,0 +1,56 @@
+package olayc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigLoadKeyDir(t *testing.T) {
+	// Simulate the kubelet layout of ConfigMap volume.
+	dir := createTestDir(t, map[string]string{
+		"..2023_01_01_00_00_00.123456789/foo.redis.host": "redis.cluster\n",
+		"..2023_01_01_00_00_00.123456789/foo.redis.port": "6380\n",
+		"..2023_01_01_00_00_00.123456789/foo.name":       "foo1",
+		"sub/foo.ignored": "ignored",
+	})
+	for _, err := range []error{
+		os.Symlink("..2023_01_01_00_00_00.123456789", filepath.Join(dir, "..data")),
+		os.Symlink("..data/foo.redis.host", filepath.Join(dir, "foo.redis.host")),
+		os.Symlink("..data/foo.redis.port", filepath.Join(dir, "foo.redis.port")),
+		os.Symlink("..data/foo.name", filepath.Join(dir, "foo.name")),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var c = New()
+	n, err := c.LoadKeyDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("got(%v)!=expect(%v) kvs loaded\n", n, 3)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"foo.name", "foo1"},
+		{"foo.redis.host", "redis.cluster"},
+		{"foo.redis.port", uint64(6380)},
+		{"sub", nil},
+		{"foo.ignored", nil},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key=%v, got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+
+	if _, err = c.LoadKeyDir(filepath.Join(dir, "not-exist")); err == nil {
+		t.Error("expect error loading not exist directory")
+	}
+}
@@ -0,0 +1,216 @@
+package olayc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// KeyNormalization is the policy of matching map keys, the keys are normalized when they're loaded and looked up.
+// The integer keys are not normalized.
+type KeyNormalization struct {
+	name string
+	fold func(string) string
+}
+
+var (
+	// Keys are matched exactly, it's the default.
+	KeyExact = KeyNormalization{name: "exact"}
+	// Keys are matched case-insensitively, e.g. 'maxConns' matches 'MAXCONNS'.
+	KeyCaseInsensitive = KeyNormalization{name: "case-insensitive", fold: strings.ToLower}
+	// Keys are matched case-insensitively with '_' and '-' ignored,
+	// e.g. 'maxConns', 'max_conns', 'max-conns' and 'MAXCONNS' are the same key.
+	KeyFolding = KeyNormalization{name: "folding", fold: foldKey}
+)
+
+// Return the name of the normalization.
+func (n KeyNormalization) String() string {
+	if len(n.name) == 0 {
+		return KeyExact.name
+	}
+	return n.name
+}
+
+// Lowercase s and remove '_' and '-'.
+func foldKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(s))
+}
+
+// Return the normalized map key.
+func (n KeyNormalization) key(k any) any {
+	if s, ok := k.(string); ok && n.fold != nil {
+		return n.fold(s)
+	}
+	return k
+}
+
+// Return the key path segments with map keys normalized.
+func (n KeyNormalization) segments(segs []pathSegment) []pathSegment {
+	if n.fold == nil {
+		return segs
+	}
+	out := make([]pathSegment, len(segs))
+	for i, seg := range segs {
+		if !seg.isIndex {
+			seg.key = n.key(seg.key)
+		}
+		out[i] = seg
+	}
+	return out
+}
+
+// Return the normalized key path, the `Root` key is kept.
+func (n KeyNormalization) path(key string) string {
+	if n.fold == nil || key == Root {
+		return key
+	}
+	return formatKeyPath(n.segments(parseKeyPath(key)))
+}
+
+// Return the configure tree v with map keys normalized, v is not modified.
+// If keys are conflicted after normalized, the lexically first key wins, the sub-trees are merged as `copyMap()`.
+func (n KeyNormalization) tree(v any) any {
+	if n.fold == nil {
+		return v
+	}
+	switch x := v.(type) {
+	case map[any]any:
+		keys := make([]any, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		m := make(map[any]any, len(x))
+		for _, k := range keys {
+			nk, sub := n.key(k), n.tree(x[k])
+			prev, ok := m[nk]
+			if !ok {
+				m[nk] = sub
+				continue
+			}
+			prevM, ok1 := prev.(map[any]any)
+			subM, ok2 := sub.(map[any]any)
+			if ok1 && ok2 {
+				copyMap(prevM, subM)
+			}
+		}
+		return m
+	case []any:
+		sl := make([]any, len(x))
+		for i, sub := range x {
+			sl[i] = n.tree(sub)
+		}
+		return sl
+	}
+	return v
+}
+
+// Return m with normalized key paths, e.g. the line numbers of keys.
+func normalizeKeys[V any](n KeyNormalization, m map[string]V) map[string]V {
+	if n.fold == nil || m == nil {
+		return m
+	}
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		if _, ok := out[n.path(k)]; !ok {
+			out[n.path(k)] = v
+		}
+	}
+	return out
+}
+
+// Return the configure tree v with map keys renamed to the yaml field names of type t, so it's unmarshalled to t.
+// The yaml field name is the 'yaml' tag, or the lowercased field name if there is no tag.
+func (n KeyNormalization) align(v any, t reflect.Type) any {
+	if n.fold == nil || t == nil {
+		return v
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[any]any)
+		if !ok {
+			return v
+		}
+		fields := make(map[any]reflect.StructField)
+		n.structFields(t, fields)
+		out := make(map[any]any, len(m))
+		for k, sub := range m {
+			f, ok := fields[n.key(k)]
+			if !ok {
+				out[k] = sub
+				continue
+			}
+			out[yamlFieldName(f)] = n.align(sub, f.Type)
+		}
+		return out
+	case reflect.Map:
+		m, ok := v.(map[any]any)
+		if !ok {
+			return v
+		}
+		out := make(map[any]any, len(m))
+		for k, sub := range m {
+			out[k] = n.align(sub, t.Elem())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		sl, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		out := make([]any, len(sl))
+		for i, sub := range sl {
+			out[i] = n.align(sub, t.Elem())
+		}
+		return out
+	}
+	return v
+}
+
+// Collect the exported fields of struct t by normalized yaml field names, the inline fields are flattened.
+func (n KeyNormalization) structFields(t reflect.Type, fields map[any]reflect.StructField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		name := yamlFieldName(f)
+		if name == "-" {
+			continue
+		}
+		_, flags, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if strings.Contains(flags, "inline") && f.Type.Kind() == reflect.Struct {
+			n.structFields(f.Type, fields)
+			continue
+		}
+		fields[n.key(name)] = f
+	}
+}
+
+// Return the yaml field name of struct field f.
+func yamlFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if len(name) == 0 {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// SetKeyNormalization sets the policy of matching keys, e.g. `KeyFolding` matches the camelCase key 'maxConns'
+// with the environment variable 'FOO_MAXCONNS'. The default is `KeyExact`.
+// It applies to the sources loaded after it's set, the keys of the merged configure are normalized,
+// and the keys of `Get()`, `Query()`, `Origin()` and `Unmarshal()` are normalized as well.
+func (c *OlayConfig) SetKeyNormalization(n KeyNormalization) {
+	c.keyNorm = n
+}
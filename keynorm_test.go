@@ -0,0 +1,125 @@
+package olayc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyNormalizationPath(t *testing.T) {
+	for i, test := range []struct {
+		norm   KeyNormalization
+		key    string
+		expect string
+	}{
+		{KeyExact, "foo.maxConns", "foo.maxConns"},
+		{KeyCaseInsensitive, "Foo.maxConns", "foo.maxconns"},
+		{KeyCaseInsensitive, "foo.max_conns", "foo.max_conns"},
+		{KeyFolding, "foo.max_conns", "foo.maxconns"},
+		{KeyFolding, "foo.Max-Conns", "foo.maxconns"},
+		{KeyFolding, "foo.servers[1].Host_Name", "foo.servers[1].hostname"},
+		{KeyFolding, `labels."App.Name"`, `labels."app.name"`},
+		{KeyFolding, "ports.8080", "ports.8080"},
+		{KeyFolding, Root, Root},
+	} {
+		got := test.norm.path(test.key)
+		if got != test.expect {
+			t.Errorf("[%v] %v key(%v) got(%v)!=expect(%v)\n", i, test.norm, test.key, got, test.expect)
+		}
+	}
+}
+
+func TestKeyNormalization(t *testing.T) {
+	yml := []byte(`
+foo:
+  maxConns: 10
+  idleTimeout: 30
+  Name: foo1
+  servers:
+  - hostName: s0
+`)
+	for i, test := range []struct {
+		norm   KeyNormalization
+		key    string
+		expect any
+	}{
+		{KeyExact, "foo.maxConns", 10},
+		{KeyExact, "foo.MaxConns", nil},
+		{KeyExact, "foo.idleTimeout", 30},
+		{KeyCaseInsensitive, "FOO.MAXCONNS", 100},
+		{KeyCaseInsensitive, "foo.max_conns", nil},
+		{KeyCaseInsensitive, "foo.name", "foo-args"},
+		{KeyFolding, "foo.max_conns", 100},
+		{KeyFolding, "foo.idle-timeout", 60},
+		{KeyFolding, "foo.servers[0].host_name", "s0"},
+	} {
+		c := New()
+		c.SetKeyNormalization(test.norm)
+		_, err := c.LoadArgs([]string{"-foo.name=foo-args"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.LoadEnvs([]string{"FOO_MAXCONNS=100"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.LoadKVs([]KV{{"foo.idle_timeout", 60}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.LoadYaml(yml)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := c.Get(test.key).v
+		if v, ok := got.(uint64); ok {
+			got = int(v)
+		}
+		if got != test.expect {
+			t.Errorf("[%v] %v key(%v) got(%v)!=expect(%v)\n", i, test.norm, test.key, got, test.expect)
+		}
+	}
+}
+
+func TestKeyNormalizationUnmarshal(t *testing.T) {
+	c := New()
+	c.SetKeyNormalization(KeyFolding)
+	_, err := c.LoadPrefixedEnvs([]string{"FOO__MAXCONNS=100", "FOO__SERVERS__0__HOSTNAME=s0-env"}, "", "__")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadYaml([]byte(`
+foo:
+  maxConns: 10
+  idle_timeout: 30
+  servers:
+  - hostName: s0
+    port: 80
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type server struct {
+		HostName string `yaml:"hostName"`
+		Port     int    `yaml:"port"`
+	}
+	var foo struct {
+		MaxConns    int      `yaml:"maxConns"`
+		IdleTimeout int      `yaml:"idle-timeout"`
+		Servers     []server `yaml:"servers"`
+	}
+	err = c.Unmarshal("foo", &foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foo.MaxConns != 100 || foo.IdleTimeout != 30 {
+		t.Errorf("got(%+v)\n", foo)
+	}
+	expect := []server{{"s0-env", 80}}
+	if !reflect.DeepEqual(foo.Servers, expect) {
+		t.Errorf("got(%v)!=expect(%v)\n", foo.Servers, expect)
+	}
+	if got := c.Origin("foo.maxConns"); got.Kind != "envs" {
+		t.Errorf("Origin got(%v)\n", got)
+	}
+}
@@ -0,0 +1,207 @@
+package olayc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is a segment of key path, which is a map key or an array index.
+type pathSegment struct {
+	key     any
+	index   int
+	isIndex bool
+}
+
+// Parse key path to segments. The grammar is:
+//
+//	foo.name                          # Map keys are seperated by '.'
+//	foo.servers[1].port               # Array index
+//	foo.matrix[0][1]                  # Nested array index
+//	labels."app.kubernetes.io/name"   # Quoted map key, '\"' and '\\' are escaped in quotes
+//	labels.app\.kubernetes\.io/name   # Escaped '.', '[', '"' and '\' in unquoted map key
+//	ports.8080                        # Integer map key, the quoted key "8080" is a string key
+//
+// The parsing is lenient, the invalid parts are taken literally, e.g. 'foo[x]' is a map key and unterminated quote ends at the end.
+// The index greater than `maxArrayIndex` is invalid, e.g. 'foo[100000]' is a map key.
+func parseKeyPath(path string) []pathSegment {
+	var segs []pathSegment
+	i := 0
+	for {
+		var sb strings.Builder
+		quoted := false
+		if i < len(path) && path[i] == '"' {
+			quoted = true
+			for i++; i < len(path) && path[i] != '"'; i++ {
+				if path[i] == '\\' && i+1 < len(path) {
+					i++
+				}
+				sb.WriteByte(path[i])
+			}
+			i++
+		}
+		for ; i < len(path) && path[i] != '.' && indexAt(path, i) < 0; i++ {
+			if path[i] == '\\' && i+1 < len(path) {
+				i++
+			}
+			sb.WriteByte(path[i])
+		}
+
+		// The index directly after '.' or at the beginning has no map key, e.g. '[0]'.
+		name := sb.String()
+		if quoted || len(name) > 0 || indexAt(path, i) < 0 {
+			var key any = name
+			if n, err := strconv.Atoi(name); err == nil && !quoted && strconv.Itoa(n) == name && n >= 0 {
+				key = n
+			}
+			segs = append(segs, pathSegment{key: key})
+		}
+
+		for indexAt(path, i) >= 0 {
+			end := strings.IndexByte(path[i:], ']') + i
+			n := indexAt(path, i)
+			segs = append(segs, pathSegment{index: n, isIndex: true})
+			i = end + 1
+		}
+
+		if i >= len(path) || path[i] != '.' {
+			return segs
+		}
+		i++
+	}
+}
+
+// maxArrayIndex is the max array index of key paths, the holes before the index are allocated.
+// The larger indexes are taken literally as map keys, e.g. 'servers[100000]'.
+const maxArrayIndex = 1024
+
+// Return the array index if path[i:] starts with '[n]' and n <= `maxArrayIndex`, otherwise return -1.
+func indexAt(path string, i int) int {
+	if i >= len(path) || path[i] != '[' {
+		return -1
+	}
+	end := strings.IndexByte(path[i:], ']')
+	if end < 2 {
+		return -1
+	}
+	digits := path[i+1 : i+end]
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return -1
+		}
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n > maxArrayIndex {
+		return -1
+	}
+	return n
+}
+
+// Format segments to key path, it's the canonical form of the parsed key path.
+func formatKeyPath(segs []pathSegment) string {
+	var sb strings.Builder
+	for i, seg := range segs {
+		if seg.isIndex {
+			fmt.Fprintf(&sb, "[%v]", seg.index)
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(quoteKey(seg.key))
+	}
+	return sb.String()
+}
+
+// Return the canonical form of key path, e.g. 'labels.app\.name' is 'labels."app.name"'.
+func canonicalKey(path string) string {
+	return formatKeyPath(parseKeyPath(path))
+}
+
+// Return the map key in key path, it's quoted if it contains '.', '[', ']', '"', '\' or it's empty.
+func quoteKey(k any) string {
+	s := fmt.Sprint(k)
+	if len(s) > 0 && !strings.ContainsAny(s, ".[]\"\\") {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// Return the value of map key, the key is matched by its formatted string if it's not found by type, e.g. 8080 matches "8080".
+func mapValue(m map[any]any, key any) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	s := fmt.Sprint(key)
+	for k, v := range m {
+		if fmt.Sprint(k) == s {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// arrayHole is the type of `hole`.
+type arrayHole struct{}
+
+// hole is the array item which is not set, e.g. the item 0 of `-servers[1].port=9000`.
+// The holes are filled by the items of lower layers, or they are null.
+var hole = arrayHole{}
+
+// Return if v is an array hole.
+func isHole(v any) bool {
+	_, ok := v.(arrayHole)
+	return ok
+}
+
+// Set value at the key path in the configure tree node, the existing value wins. Return the node.
+// The node is `hole` if it doesn't exist.
+// The arrays are built as merge-by-index directives, thus they're merged with the arrays of lower layers by index,
+// refer to `MergeKey`.
+func setPath(node any, segs []pathSegment, value any) any {
+	if len(segs) == 0 {
+		if isHole(node) {
+			return value
+		}
+		return node
+	}
+
+	seg := segs[0]
+	if seg.isIndex {
+		d, ok := node.(map[any]any)
+		if isHole(node) {
+			d = map[any]any{MergeKey: MergeByIndex.name, mergeItemsKey: []any{}}
+		} else if !ok || d[MergeKey] != MergeByIndex.name {
+			return node
+		}
+		items, _ := d[mergeItemsKey].([]any)
+		for len(items) <= seg.index {
+			items = append(items, hole)
+		}
+		items[seg.index] = setPath(items[seg.index], segs[1:], value)
+		d[mergeItemsKey] = items
+		return d
+	}
+
+	m, ok := node.(map[any]any)
+	if isHole(node) {
+		m = make(map[any]any)
+	} else if !ok || m[MergeKey] == MergeByIndex.name {
+		return node
+	}
+	sub, ok := m[seg.key]
+	if !ok {
+		sub = hole
+	}
+	m[seg.key] = setPath(sub, segs[1:], value)
+	return m
+}
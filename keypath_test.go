@@ -0,0 +1,165 @@
+package olayc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyPath(t *testing.T) {
+	key := func(k any) pathSegment { return pathSegment{key: k} }
+	index := func(n int) pathSegment { return pathSegment{index: n, isIndex: true} }
+	for i, test := range []struct {
+		path      string
+		expect    []pathSegment
+		canonical string
+	}{
+		{"foo.name", []pathSegment{key("foo"), key("name")}, "foo.name"},
+		{"foo.servers[1].port", []pathSegment{key("foo"), key("servers"), index(1), key("port")}, "foo.servers[1].port"},
+		{"matrix[0][12]", []pathSegment{key("matrix"), index(0), index(12)}, "matrix[0][12]"},
+		{`labels."app.kubernetes.io/name"`, []pathSegment{key("labels"), key("app.kubernetes.io/name")}, `labels."app.kubernetes.io/name"`},
+		{`labels.app\.kubernetes\.io/name`, []pathSegment{key("labels"), key("app.kubernetes.io/name")}, `labels."app.kubernetes.io/name"`},
+		{`a."b\"c\\d"`, []pathSegment{key("a"), key(`b"c\d`)}, `a."b\"c\\d"`},
+		{"ports.8080", []pathSegment{key("ports"), key(8080)}, "ports.8080"},
+		{`ports."8080"`, []pathSegment{key("ports"), key("8080")}, "ports.8080"},
+		{"ports.007", []pathSegment{key("ports"), key("007")}, "ports.007"},
+		{"foo[x].bar", []pathSegment{key("foo[x]"), key("bar")}, `"foo[x]".bar`},
+		{"foo[1024]", []pathSegment{key("foo"), index(1024)}, "foo[1024]"},
+		{"foo[1025]", []pathSegment{key("foo[1025]")}, `"foo[1025]"`},
+		{`foo."bar`, []pathSegment{key("foo"), key("bar")}, "foo.bar"},
+		{"a..b", []pathSegment{key("a"), key(""), key("b")}, `a."".b`},
+	} {
+		got := parseKeyPath(test.path)
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("[%v] path(%v) got(%v)!=expect(%v)\n", i, test.path, got, test.expect)
+		}
+		if canonical := canonicalKey(test.path); canonical != test.canonical {
+			t.Errorf("[%v] path(%v) canonical got(%v)!=expect(%v)\n", i, test.path, canonical, test.canonical)
+		}
+	}
+}
+
+func TestKeyPathGet(t *testing.T) {
+	c := New()
+	err := c.LoadYaml([]byte(`
+labels:
+  app.kubernetes.io/name: foo
+ports:
+  8080: http
+servers:
+- host: s0
+  port: 80
+- host: s1
+  port: 81
+matrix: [[1, 2], [3, 4]]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{`labels."app.kubernetes.io/name"`, "foo"},
+		{`labels.app\.kubernetes\.io/name`, "foo"},
+		{"labels.app", nil},
+		{"ports.8080", "http"},
+		{`ports."8080"`, "http"},
+		{"servers[1].host", "s1"},
+		{"servers[2].host", nil},
+		{"servers.host", nil},
+		{"matrix[1][0]", 3},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+	if got := c.Origin("servers[1].host"); got.Kind != "bytes" {
+		t.Errorf("Origin got(%v)\n", got)
+	}
+}
+
+func TestKeyPathLoadKVs(t *testing.T) {
+	c := New()
+	_, err := c.LoadArgs([]string{
+		"-servers[1].port=9000",
+		"-servers[2].host=s2",
+		`-labels."app.kubernetes.io/name"=bar`,
+		"-ports.8080=https",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.LoadPrefixedEnvs([]string{"SERVERS__0__PORT=8000"}, "", "__")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadYaml([]byte(`
+labels:
+  app.kubernetes.io/name: foo
+ports:
+  8080: http
+servers:
+- host: s0
+  port: 80
+- host: s1
+  port: 81
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []any{
+		map[any]any{"host": "s0", "port": uint64(8000)},
+		map[any]any{"host": "s1", "port": uint64(9000)},
+		map[any]any{"host": "s2"},
+	}
+	got := c.Get("servers")
+	if !reflect.DeepEqual(got.v, expect) {
+		t.Errorf("servers got(%v)!=expect(%v)\n", got.v, expect)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{`labels."app.kubernetes.io/name"`, "bar"},
+		{"ports.8080", "https"},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+}
+
+func TestKeyPathHoles(t *testing.T) {
+	c := New()
+	_, err := c.LoadKVs([]KV{{"servers[1]", "s1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.Get("servers")
+	if expect := []any{nil, "s1"}; !reflect.DeepEqual(got.v, expect) {
+		t.Errorf("got(%v)!=expect(%v)\n", got.v, expect)
+	}
+	if v := c.Get("servers[0]"); !v.IsNull() {
+		t.Errorf("hole got(%v) is not null\n", v.v)
+	}
+}
+
+func TestKeyPathMaxIndex(t *testing.T) {
+	c := New()
+	_, err := c.LoadArgs([]string{"-servers[300000000].port=1", "-hosts[1024]=h1024"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := c.Get("servers"); !v.IsMissing() {
+		t.Errorf("got(%v) is not missing\n", v.v)
+	}
+	if got := c.Get("servers[300000000].port"); got.v != uint64(1) {
+		t.Errorf("got(%v)!=expect(%v)\n", got.v, 1)
+	}
+	if got := c.Get("hosts"); len(got.v.([]any)) != maxArrayIndex+1 {
+		t.Errorf("got(%v)!=expect(%v) items\n", len(got.v.([]any)), maxArrayIndex+1)
+	}
+}
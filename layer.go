@@ -0,0 +1,158 @@
+package olayc
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Layer is the configure tree of one loaded source, kept as it is before overlayed with other layers.
+type Layer struct {
+	name   string
+	origin Origin
+	tree   map[any]any
+	norm   KeyNormalization
+}
+
+// Name returns the layer name, which is the source name, e.g. 'args', '/etc/app/app.yaml'.
+func (l *Layer) Name() string {
+	return l.name
+}
+
+// Origin returns the origin of the layer's source, the line number is not set.
+func (l *Layer) Origin() Origin {
+	return l.origin
+}
+
+// Get value with the given key in the layer, return nil if doesn't exist or the layer is nil.
+// The key is as same as `OlayConfig.Get()`, the value may be shadowed by upper layers.
+func (l *Layer) Get(key string) Value {
+	if l == nil {
+		return Value{}
+	}
+	return lookupValue(l.tree, l.norm.path(key))
+}
+
+// Keys returns all leaf keys of the layer in lexical order.
+func (l *Layer) Keys() []string {
+	if l == nil {
+		return nil
+	}
+	var keys []string
+	walkLeaves("", l.tree, func(key string, _ any) {
+		if len(key) > 0 {
+			keys = append(keys, key)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Append the layer of m, it's beneath the previously appended layers.
+// The tree is copied, because the merged configure shares sub-trees with m.
+func (c *OlayConfig) addLayer(m map[any]any, origin Origin) {
+	name := origin.Name
+	if len(name) == 0 {
+		name = origin.Kind
+	}
+	c.layers = append(c.layers, &Layer{name, origin, cloneValue(m).(map[any]any), c.keyNorm})
+}
+
+// Layers returns the loaded layers ordered from top to bottom, the top layer is visible if there is key conflicted among layers.
+func (c *OlayConfig) Layers() []*Layer {
+	layers := make([]*Layer, len(c.layers))
+	copy(layers, c.layers)
+	return layers
+}
+
+// Layer returns the top-most layer matching name, return nil if not found.
+// The name matches as same as `WithPriority()`, e.g. 'app.yaml' matches '/etc/app/app.yaml'.
+func (c *OlayConfig) Layer(name string) *Layer {
+	i := c.layerIndex(name)
+	if i < 0 {
+		return nil
+	}
+	return c.layers[i]
+}
+
+// Return index of the top-most layer matching name, the exact name is prior. Return -1 if not found.
+func (c *OlayConfig) layerIndex(name string) int {
+	for i, l := range c.layers {
+		if l.name == name {
+			return i
+		}
+	}
+	for i, l := range c.layers {
+		if matchSourceName(l.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ShadowedKey is a key defined in both layers, the value of the lower layer is shadowed by the upper layer.
+type ShadowedKey struct {
+	Key        string
+	Upper      string
+	UpperValue Value
+	Lower      string
+	LowerValue Value
+}
+
+// LayerDiff is the difference of leaf keys between two layers, the keys are in lexical order.
+type LayerDiff struct {
+	// Added are keys only in the layer b.
+	Added []string
+	// Removed are keys only in the layer a.
+	Removed []string
+	// Shadowed are keys in both layers.
+	Shadowed []ShadowedKey
+}
+
+// Diff compares leaf keys of layer a and layer b, the layers are found by name as same as `Layer()`.
+// The keys only in b are added, the keys only in a are removed,
+// the keys in both are shadowed by the upper layer whichever of a and b it is.
+// Return error if any layer is not found.
+func (c *OlayConfig) Diff(a string, b string) (*LayerDiff, error) {
+	ia := c.layerIndex(a)
+	if ia < 0 {
+		return nil, errors.Errorf("layer %v not found", a)
+	}
+	ib := c.layerIndex(b)
+	if ib < 0 {
+		return nil, errors.Errorf("layer %v not found", b)
+	}
+	la, lb := c.layers[ia], c.layers[ib]
+	upper, lower := la, lb
+	if ib < ia {
+		upper, lower = lb, la
+	}
+
+	diff := &LayerDiff{}
+	keysA := la.Keys()
+	inA := make(map[string]bool)
+	for _, key := range keysA {
+		inA[key] = true
+	}
+	inB := make(map[string]bool)
+	for _, key := range lb.Keys() {
+		inB[key] = true
+		if !inA[key] {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	for _, key := range keysA {
+		if !inB[key] {
+			diff.Removed = append(diff.Removed, key)
+			continue
+		}
+		diff.Shadowed = append(diff.Shadowed, ShadowedKey{
+			Key:        key,
+			Upper:      upper.name,
+			UpperValue: upper.Get(key),
+			Lower:      lower.name,
+			LowerValue: lower.Get(key),
+		})
+	}
+	return diff, nil
+}
@@ -0,0 +1,97 @@
+package olayc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayers(t *testing.T) {
+	c := New()
+	c.AddSource(ArgsSource([]string{"-foo.name=foo-args"}))
+	c.AddSource(SourceWithPriority(YamlSource([]byte(`
+foo:
+  name: foo-defaults
+  id: 1
+  redis:
+    host: localhost
+`)), PriorityDefaults))
+	c.AddSource(KVsSource("/etc/app/ops", PriorityFile, []KV{
+		{"foo.id", 2},
+		{"foo.redis.port", 6379},
+	}))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, l := range c.Layers() {
+		names = append(names, l.Name())
+	}
+	expectNames := []string{"args", "/etc/app/ops", "yaml"}
+	if !reflect.DeepEqual(names, expectNames) {
+		t.Errorf("Layers got(%v)!=expect(%v)\n", names, expectNames)
+	}
+
+	for i, test := range []struct {
+		layer  string
+		key    string
+		expect any
+	}{
+		{"args", "foo.name", "foo-args"},
+		{"args", "foo.id", nil},
+		{"yaml", "foo.name", "foo-defaults"},
+		{"yaml", "foo.id", 1},
+		// The merged sub-tree doesn't change the layer.
+		{"yaml", "foo.redis.port", nil},
+		{"ops", "foo.id", 2},
+		{"/etc/app/ops", "foo.redis.port", 6379},
+		{"notexist", "foo.id", nil},
+	} {
+		v := c.Layer(test.layer).Get(test.key)
+		if v.v != test.expect {
+			t.Errorf("[%v] layer(%v) key(%v) got(%v)!=expect(%v)\n", i, test.layer, test.key, v.v, test.expect)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	c := New()
+	_, err := c.LoadKVs([]KV{
+		{"foo.id", 2},
+		{"foo.redis.port", 6380},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadYaml([]byte(`
+foo:
+  name: foo-defaults
+  id: 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := c.Diff("yaml", "kvs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"foo.redis.port"}; !reflect.DeepEqual(diff.Added, expect) {
+		t.Errorf("Added got(%v)!=expect(%v)\n", diff.Added, expect)
+	}
+	if expect := []string{"foo.name"}; !reflect.DeepEqual(diff.Removed, expect) {
+		t.Errorf("Removed got(%v)!=expect(%v)\n", diff.Removed, expect)
+	}
+	expectShadowed := []ShadowedKey{
+		{"foo.id", "kvs", Value{2}, "yaml", Value{1}},
+	}
+	if !reflect.DeepEqual(diff.Shadowed, expectShadowed) {
+		t.Errorf("Shadowed got(%v)!=expect(%v)\n", diff.Shadowed, expectShadowed)
+	}
+
+	_, err = c.Diff("yaml", "notexist")
+	if err == nil {
+		t.Errorf("Diff with not exist layer should fail\n")
+	}
+}
@@ -74,16 +74,56 @@ import (
 //     port: 6380
 // `
 func copyMap(dst map[any]any, src map[any]any) {
-	copyMapDFS(dst, src)
+	(&mapCopier{}).copy(dst, src, "")
+}
+
+// mapCopier copies map values from src to dst as same as `copyMap()`, with optional hooks.
+type mapCopier struct {
+	// onCopy is called with the dotted key path of each src value copied to dst.
+	onCopy func(key string, v any)
+	// onArrays is called if both dst and src values are arrays, the dst value is replaced with the returned array.
+	onArrays func(key string, dst []any, src []any) []any
+	// sealed are the dotted key paths of dst whose sub-trees are not merged with src,
+	// a dst sub-tree is sealed if the src value is null or deleted. Sealing is disabled if it's nil.
+	sealed map[string]bool
 }
 
 // Deep first search copy.
-func copyMapDFS(dst map[any]any, src map[any]any) {
+func (cp *mapCopier) copy(dst map[any]any, src map[any]any, prefix string) {
 	for k, valSrc := range src {
+		key := joinKey(prefix, k)
+		if cp.sealed[key] {
+			continue
+		}
+
 		// Key doesn't exisit in dst, copy it to dst.
 		valDst, ok := dst[k]
 		if !ok {
 			dst[k] = valSrc
+			if cp.onCopy != nil {
+				cp.onCopy(key, valSrc)
+			}
+			continue
+		}
+
+		// Null and deleted values are leaves.
+		if valDst == nil || isDeleted(valDst) {
+			continue
+		}
+
+		// The dst sub-tree shadows the null or deleted src value, and the sub-trees of further src are shadowed too.
+		if _, isDstMap := valDst.(map[any]any); isDstMap && (valSrc == nil || isDeleted(valSrc)) {
+			if cp.sealed != nil {
+				cp.sealed[key] = true
+			}
+			continue
+		}
+
+		// Both are arrays, merge them if hooked.
+		arrDst, isDstArray := valDst.([]any)
+		arrSrc, isSrcArray := valSrc.([]any)
+		if isDstArray && isSrcArray && cp.onArrays != nil {
+			dst[k] = cp.onArrays(key, arrDst, arrSrc)
 			continue
 		}
 
@@ -107,8 +147,48 @@ func copyMapDFS(dst map[any]any, src map[any]any) {
 		if !isDstMapType || !isSrcMapType {
 			continue
 		}
-		copyMapDFS(nextDst, nextSrc)
+		cp.copy(nextDst, nextSrc, key)
+	}
+}
+
+// Walk the leaves of v with DFS, call fn with the dotted key path of each leaf.
+// Scalar values, arrays and empty maps are leaves.
+func walkLeaves(prefix string, v any, fn func(key string, v any)) {
+	m, ok := v.(map[any]any)
+	if !ok || len(m) == 0 {
+		fn(prefix, v)
+		return
+	}
+	for k, sub := range m {
+		walkLeaves(joinKey(prefix, k), sub, fn)
+	}
+}
+
+// Return deep copy of v, the maps and slices are copied recursively.
+func cloneValue(v any) any {
+	switch x := v.(type) {
+	case map[any]any:
+		m := make(map[any]any, len(x))
+		for k, sub := range x {
+			m[k] = cloneValue(sub)
+		}
+		return m
+	case []any:
+		sl := make([]any, len(x))
+		for i, sub := range x {
+			sl[i] = cloneValue(sub)
+		}
+		return sl
+	}
+	return v
+}
+
+// Join the map key to the key path prefix, the key is quoted if necessary, refer to `quoteKey()`.
+func joinKey(prefix string, k any) string {
+	if len(prefix) == 0 {
+		return quoteKey(k)
 	}
+	return prefix + "." + quoteKey(k)
 }
 
 // Convert map[string]any to map[any]any.
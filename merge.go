@@ -0,0 +1,228 @@
+package olayc
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MergeKey is the key of in-file array merge directive, e.g.
+	//
+	//	servers:
+	//	  $merge: append
+	//	  items: [server3, server4]
+	//
+	// The directive is replaced with the items, and the items are merged with the same key of lower layers by the strategy,
+	// which is one of 'replace', 'append', 'prepend', 'union-by-key' and 'merge-by-index'.
+	// For 'union-by-key', the key field of items is set by `MergeKeyKey`, e.g. `$key: name`.
+	// The directive is prior to the strategies set on OlayConfig, refer to `OlayConfig.SetArrayMerge()`.
+	MergeKey = "$merge"
+
+	// MergeKeyKey is the key of the item key field in array merge directive, refer to `MergeUnionByKey()`.
+	MergeKeyKey = "$key"
+
+	// mergeItemsKey is the key of items in array merge directive.
+	mergeItemsKey = "items"
+)
+
+// MergeStrategy is the strategy of merging arrays with the same key among layers.
+// The zero value is `MergeReplace`.
+type MergeStrategy struct {
+	name string
+	key  string
+}
+
+var (
+	// MergeReplace keeps the array of the upper layer, the arrays of lower layers are ignored. It's the default strategy.
+	MergeReplace = MergeStrategy{name: "replace"}
+	// MergeAppend appends the items of the upper layer to the array of the lower layer.
+	MergeAppend = MergeStrategy{name: "append"}
+	// MergePrepend prepends the items of the upper layer to the array of the lower layer.
+	MergePrepend = MergeStrategy{name: "prepend"}
+	// MergeByIndex merges the items at the same index, the item of the upper layer wins,
+	// and sub-trees are merged as same as maps. The longer array's extra items are kept.
+	MergeByIndex = MergeStrategy{name: "merge-by-index"}
+)
+
+// MergeUnionByKey returns the strategy unions the items identified by the key field,
+// e.g. `MergeUnionByKey("name")` identifies items by their 'name' field.
+// The items of the lower layer keep their order, the item of the upper layer with the same key is merged above it,
+// and the other items of the upper layer are appended.
+// If the key is empty, the items are identified by their values, thus the duplicated items are removed.
+func MergeUnionByKey(key string) MergeStrategy {
+	return MergeStrategy{name: "union-by-key", key: key}
+}
+
+// String returns the strategy name, e.g. 'append', or 'union-by-key(name)'.
+func (s MergeStrategy) String() string {
+	if len(s.name) == 0 {
+		return MergeReplace.name
+	}
+	if len(s.key) > 0 {
+		return s.name + "(" + s.key + ")"
+	}
+	return s.name
+}
+
+// Return the strategy by name, the key is used by 'union-by-key' only.
+func parseMergeStrategy(name string, key string) (MergeStrategy, error) {
+	switch name {
+	case MergeReplace.name, MergeAppend.name, MergePrepend.name, MergeByIndex.name:
+		return MergeStrategy{name: name}, nil
+	case "union-by-key":
+		return MergeUnionByKey(key), nil
+	}
+	return MergeStrategy{}, errors.Errorf("unknown merge strategy: %v", name)
+}
+
+// Merge array upper above array lower by the strategy.
+func (s MergeStrategy) merge(upper []any, lower []any) []any {
+	switch s.name {
+	case MergeAppend.name:
+		return append(append([]any{}, lower...), upper...)
+	case MergePrepend.name:
+		return append(append([]any{}, upper...), lower...)
+	case MergeByIndex.name:
+		out := append([]any{}, upper...)
+		for i, item := range lower {
+			if i < len(out) {
+				out[i] = mergeItem(out[i], item)
+			} else {
+				out = append(out, item)
+			}
+		}
+		return out
+	case "union-by-key":
+		out := append([]any{}, lower...)
+		for _, item := range upper {
+			found := false
+			for i := range out {
+				if s.sameItem(item, out[i]) {
+					out[i] = mergeItem(item, out[i])
+					found = true
+					break
+				}
+			}
+			if !found {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return upper
+}
+
+// Return if the items are identified by the same key, or the same value if key is empty.
+func (s MergeStrategy) sameItem(a any, b any) bool {
+	if len(s.key) == 0 {
+		return reflect.DeepEqual(a, b)
+	}
+	ma, ok := a.(map[any]any)
+	if !ok {
+		return false
+	}
+	mb, ok := b.(map[any]any)
+	if !ok {
+		return false
+	}
+	ka, ok := ma[s.key]
+	if !ok {
+		return false
+	}
+	kb, ok := mb[s.key]
+	return ok && reflect.DeepEqual(ka, kb)
+}
+
+// mergeArray is an array of a layer with the strategy merging it above the lower layers.
+type mergeArray struct {
+	items    []any
+	strategy MergeStrategy
+}
+
+// Merge the arrays ordered from top to bottom, the items are copied thus the arrays are kept unchanged.
+func mergeArrays(arrays []mergeArray) []any {
+	var out []any
+	for i := len(arrays) - 1; i >= 0; i-- {
+		items := cloneValue(arrays[i].items).([]any)
+		if i == len(arrays)-1 {
+			out = items
+			continue
+		}
+		out = arrays[i].strategy.merge(items, out)
+	}
+	return out
+}
+
+// Merge item upper above item lower, sub-trees are merged as same as maps, otherwise the upper wins.
+// The upper hole is filled by the lower item.
+func mergeItem(upper any, lower any) any {
+	if isHole(upper) {
+		return lower
+	}
+	mu, ok := upper.(map[any]any)
+	if !ok {
+		return upper
+	}
+	ml, ok := lower.(map[any]any)
+	if !ok {
+		return upper
+	}
+	copyMap(mu, ml)
+	return mu
+}
+
+// Replace array merge directives in m with their items, the strategies are recorded in out keyed by dotted key paths.
+// Directives in arrays are not resolved.
+func extractMergeDirectives(m map[any]any, prefix string, out map[string]MergeStrategy) error {
+	for k, v := range m {
+		sub, ok := v.(map[any]any)
+		if !ok {
+			continue
+		}
+		key := joinKey(prefix, k)
+		name, ok := sub[MergeKey]
+		if !ok {
+			if err := extractMergeDirectives(sub, key, out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fieldKey, _ := sub[MergeKeyKey].(string)
+		strategy, err := parseMergeStrategy(fmt.Sprint(name), fieldKey)
+		if err != nil {
+			return errors.Wrapf(err, "key %v", key)
+		}
+		items, ok := sub[mergeItemsKey].([]any)
+		if !ok && sub[mergeItemsKey] != nil {
+			return errors.Errorf("key %v: %v of %v directive must be an array", key, mergeItemsKey, MergeKey)
+		}
+		m[k] = items
+		out[key] = strategy
+	}
+	return nil
+}
+
+// SetArrayMerge sets the default strategy of merging arrays among layers, it's `MergeReplace` if not set.
+// It applies to the layers loaded afterwards.
+func (c *OlayConfig) SetArrayMerge(strategy MergeStrategy) {
+	c.arrayMerge = strategy
+}
+
+// SetArrayMergeAt sets the strategy of merging arrays of the dotted key path, e.g. 'foo.servers'.
+// It's prior to the default strategy, and the in-file directive is prior to it, refer to `MergeKey`.
+func (c *OlayConfig) SetArrayMergeAt(key string, strategy MergeStrategy) {
+	c.arrayMergeAt[canonicalKey(key)] = strategy
+}
+
+// Return the configured strategy of the key path.
+func (c *OlayConfig) arrayMergeOf(key string) MergeStrategy {
+	for k, strategy := range c.arrayMergeAt {
+		if c.keyNorm.path(k) == key {
+			return strategy
+		}
+	}
+	return c.arrayMerge
+}
@@ -0,0 +1,139 @@
+package olayc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeStrategy(t *testing.T) {
+	upper := []any{
+		map[any]any{"name": "b", "port": 2},
+		map[any]any{"name": "c"},
+	}
+	lower := []any{
+		map[any]any{"name": "a", "port": 1},
+		map[any]any{"name": "b", "host": "hb"},
+	}
+	for i, test := range []struct {
+		strategy MergeStrategy
+		upper    []any
+		lower    []any
+		expect   []any
+	}{
+		{MergeReplace, []any{1, 2}, []any{3}, []any{1, 2}},
+		{MergeStrategy{}, []any{1, 2}, []any{3}, []any{1, 2}},
+		{MergeAppend, []any{1, 2}, []any{3}, []any{3, 1, 2}},
+		{MergePrepend, []any{1, 2}, []any{3}, []any{1, 2, 3}},
+		{MergeByIndex, []any{1}, []any{3, 4}, []any{1, 4}},
+		{MergeUnionByKey(""), []any{1, 2}, []any{2, 3}, []any{2, 3, 1}},
+		{MergeUnionByKey("name"), upper, lower, []any{
+			map[any]any{"name": "a", "port": 1},
+			map[any]any{"name": "b", "port": 2, "host": "hb"},
+			map[any]any{"name": "c"},
+		}},
+		{MergeByIndex, []any{map[any]any{"port": 2}}, []any{map[any]any{"port": 1, "host": "h"}}, []any{
+			map[any]any{"port": 2, "host": "h"},
+		}},
+	} {
+		got := test.strategy.merge(test.upper, test.lower)
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("[%v] %v got(%v)!=expect(%v)\n", i, test.strategy, got, test.expect)
+		}
+	}
+}
+
+func TestArrayMerge(t *testing.T) {
+	var top = []byte(`
+servers:
+  $merge: append
+  items: [s3]
+ports: [8080]
+labels: [a]
+`)
+	var middle = []byte(`
+servers: [s2]
+ports: [9090]
+labels: [b]
+`)
+	var bottom = []byte(`
+servers: [s1]
+ports: [7070]
+labels: [c]
+`)
+
+	c := New()
+	c.SetArrayMerge(MergePrepend)
+	c.SetArrayMergeAt("ports", MergeReplace)
+	for _, data := range [][]byte{top, middle, bottom} {
+		err := c.LoadYaml(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		// s2 is prepended to s1, then s3 is appended.
+		{"servers", []any{"s2", "s1", "s3"}},
+		{"ports", []any{8080}},
+		{"labels", []any{"a", "b", "c"}},
+	} {
+		got := c.Get(test.key)
+		if !reflect.DeepEqual(got.v, test.expect) {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+
+	// The directive is removed from the layer.
+	got := c.Layer("yaml").Get("servers")
+	if !reflect.DeepEqual(got.v, []any{"s3"}) {
+		t.Errorf("Layer got(%v)!=expect(%v)\n", got.v, []any{"s3"})
+	}
+}
+
+func TestArrayMergeDirective(t *testing.T) {
+	c := New()
+	err := c.LoadYaml([]byte(`
+foo:
+  servers:
+    $merge: union-by-key
+    $key: name
+    items:
+    - name: s1
+      port: 81
+    - name: s3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadYaml([]byte(`
+foo:
+  servers:
+  - name: s1
+    port: 80
+  - name: s2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []any{
+		map[any]any{"name": "s1", "port": 81},
+		map[any]any{"name": "s2"},
+		map[any]any{"name": "s3"},
+	}
+	got := c.Get("foo.servers")
+	if !reflect.DeepEqual(got.v, expect) {
+		t.Errorf("got(%v)!=expect(%v)\n", got.v, expect)
+	}
+
+	err = c.LoadYaml([]byte(`
+bar:
+  $merge: unknown
+  items: []
+`))
+	if err == nil {
+		t.Errorf("Unknown merge strategy should fail\n")
+	}
+}
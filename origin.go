@@ -0,0 +1,326 @@
+package olayc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Origin is where the value of a key comes from.
+type Origin struct {
+	// Kind is the kind of source, e.g. "file", "args", "envs", refer to `sourceOrigin()`.
+	Kind string
+	// Name is the source name, e.g. the file path.
+	Name string
+	// Line is the line number in the file, it's 0 if the format doesn't expose line numbers.
+	// Line numbers are known for yaml, ini, properties and dotenv files.
+	Line int
+}
+
+// String returns the origin in the form "kind name:line", e.g. "file /etc/app/app.ini:12".
+// The name is omitted if it's as same as the kind, e.g. "args".
+func (o Origin) String() string {
+	s := o.Kind
+	if len(o.Name) > 0 && o.Name != o.Kind {
+		s += " " + o.Name
+	}
+	if o.Line > 0 {
+		s += fmt.Sprintf(":%v", o.Line)
+	}
+	return s
+}
+
+// lineFuncs returns line numbers of the dotted keys decoded from bytes, keyed by format.
+// The toml decoder doesn't expose the positions of keys, thus toml files have no line numbers.
+var lineFuncs = map[string]func([]byte) map[string]int{
+	"yaml":       yamlLines,
+	"ini":        iniLines,
+	"properties": propertiesLines,
+	"dotenv":     dotenvLines,
+}
+
+// Return line numbers of the dotted keys decoded from bytes, return nil if the format doesn't expose line numbers.
+func decodeLines(format string, data []byte) map[string]int {
+	fn, ok := lineFuncs[format]
+	if !ok {
+		return nil
+	}
+	return fn(data)
+}
+
+// Return line numbers of yaml keys, return nil if syntax error.
+// The arrays are leaves, the line number is of the key of array.
+func yamlLines(data []byte) map[string]int {
+	var doc yamlv3.Node
+	if yamlv3.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+	lines := make(map[string]int)
+	if len(doc.Content) > 0 {
+		walkYamlLines("", doc.Content[0], lines)
+	}
+	return lines
+}
+
+// Walk the yaml mapping node, record the line numbers of the keys with the prefix.
+func walkYamlLines(prefix string, node *yamlv3.Node, lines map[string]int) {
+	if node.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		// The merge keys '<<' are not keys of the decoded map.
+		if keyNode.Tag == "!!merge" {
+			continue
+		}
+		key := joinKey(prefix, keyNode.Value)
+		lines[key] = keyNode.Line
+		walkYamlLines(key, valueNode, lines)
+	}
+}
+
+// Return the origin of the source without line number.
+// The kind is one of "file", "stdin", "fs", "bytes", "reader", "args", "envs", "kvs", "keydir", "http", "etcd", "consul",
+// or "source" for custom sources. Custom sources can name the kind by implementing `Kind() string`.
+func sourceOrigin(src Source) Origin {
+	name := src.Name()
+	src = unwrapSource(src)
+
+	var kind string
+	switch s := src.(type) {
+	case *fileSource:
+		kind = "file"
+		if s.path == Stdin {
+			kind = "stdin"
+		}
+	case *fsSource:
+		kind = "fs"
+	case *bytesSource:
+		kind = "bytes"
+	case *readerSource:
+		kind = "reader"
+	case *kvsSource:
+		kind = "kvs"
+		if s.name == "args" || s.name == "envs" || s.name == envBindingsName {
+			kind = s.name
+		}
+	case *keyDirSource:
+		kind = "keydir"
+	case *httpSource:
+		kind = "http"
+	case *ConsulKVSource:
+		kind = "consul"
+	case interface{ Kind() string }:
+		kind = s.Kind()
+	default:
+		kind = "source"
+	}
+	return Origin{Kind: kind, Name: name}
+}
+
+// Return the source wrapped by prioritySource and profileSource.
+func unwrapSource(src Source) Source {
+	for {
+		switch s := src.(type) {
+		case *prioritySource:
+			src = s.Source
+		case *profileSource:
+			src = s.Source
+		default:
+			return src
+		}
+	}
+}
+
+// Return if any key of m has the prefix.
+func hasKeyPrefix[V any](m map[string]V, prefix string) bool {
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return m with the keys of the applied profile section mapped to the keys they override,
+// and the other profile sections removed. Return m if the profile section is not applied.
+func profileKeys[V any](m map[string]V, profile string) map[string]V {
+	prefix := ProfilesKey + "." + profile + "."
+	if m == nil || !hasKeyPrefix(m, prefix) {
+		// The profile section is not applied, the tree is untouched.
+		return m
+	}
+	out := make(map[string]V)
+	for k, v := range m {
+		if !strings.HasPrefix(k, ProfilesKey+".") {
+			out[k] = v
+		}
+	}
+	for k, v := range m {
+		if strings.HasPrefix(k, prefix) {
+			out[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return out
+}
+
+// Return names of included files of the dotted keys of the loaded source, refer to `IncludeKey`.
+// The other keys are from the source itself.
+func filesOf(src Source) map[string]string {
+	switch s := src.(type) {
+	case *prioritySource:
+		return filesOf(s.Source)
+	case *profileSource:
+		return profileKeys(filesOf(s.Source), s.profile)
+	case *fileSource:
+		return s.files
+	case *fsSource:
+		return s.files
+	}
+	return nil
+}
+
+// Return line numbers of the dotted keys of the loaded source, return nil if unknown.
+// Keys of the applied profile section are mapped to the keys they override.
+func linesOf(src Source) map[string]int {
+	switch s := src.(type) {
+	case *prioritySource:
+		return linesOf(s.Source)
+	case *profileSource:
+		return profileKeys(linesOf(s.Source), s.profile)
+	case *fileSource:
+		return s.lines
+	case *fsSource:
+		return s.lines
+	case *readerSource:
+		return s.lines
+	case *bytesSource:
+		return decodeLines(s.format, s.data)
+	}
+	return nil
+}
+
+// Copy m beneath the merged configure as a layer, and record the origins of the copied leaves.
+// The line numbers are looked up by the dotted keys in lines, which can be nil.
+// The array merge directives are resolved, refer to `MergeKey`.
+func (c *OlayConfig) merge(m map[any]any, origin Origin, lines map[string]int) error {
+	return c.mergeFiles(m, origin, lines, nil)
+}
+
+// Merge m as same as `merge()`, the origin names of the dotted keys in files are the included file names.
+func (c *OlayConfig) mergeFiles(m map[any]any, origin Origin, lines map[string]int, files map[string]string) error {
+	m = c.keyNorm.tree(m).(map[any]any)
+	lines = normalizeKeys(c.keyNorm, lines)
+	files = normalizeKeys(c.keyNorm, files)
+	directives := make(map[string]MergeStrategy)
+	err := extractMergeDirectives(m, "", directives)
+	if err != nil {
+		return err
+	}
+	c.addLayer(m, origin)
+
+	// Arrays are stacked by key paths, they are merged from bottom to top when a lower array is stacked.
+	walkLeaves("", m, func(key string, v any) {
+		items, ok := v.([]any)
+		if !ok {
+			// Other values replace the stacked arrays beneath, e.g. null or deleted.
+			if _, stacked := c.arrays[key]; stacked {
+				c.arrays[key] = append(c.arrays[key], mergeArray{nil, MergeReplace})
+			}
+			return
+		}
+		strategy, ok := directives[key]
+		if !ok {
+			strategy = c.arrayMergeOf(key)
+		}
+		strategy.key = fmt.Sprint(c.keyNorm.key(strategy.key))
+		c.arrays[key] = append(c.arrays[key], mergeArray{cloneValue(items).([]any), strategy})
+	})
+
+	cp := &mapCopier{
+		onCopy: func(key string, v any) {
+			walkLeaves(key, v, func(key string, _ any) {
+				o := origin
+				o.Line = lines[key]
+				if name, ok := files[key]; ok {
+					o.Name = name
+				}
+				c.origins[key] = o
+			})
+		},
+		onArrays: func(key string, dst []any, src []any) []any {
+			return mergeArrays(c.arrays[key])
+		},
+		sealed: c.sealed,
+	}
+	cp.copy(c.merged, m, "")
+	c.staleResolved()
+	return nil
+}
+
+// Load the source and copy it beneath the merged configure.
+func (c *OlayConfig) load(src Source) error {
+	m, err := src.Load()
+	if err != nil {
+		return err
+	}
+	return c.mergeFiles(m, sourceOrigin(src), linesOf(src), filesOf(src))
+}
+
+// Origin returns where the value of the key comes from, e.g. `Origin("foo.redis.host").String()` is "file app.ini:12".
+// Only leaf keys and array items have origins, it returns the zero Origin if the key doesn't exist or it's not a leaf.
+func (c *OlayConfig) Origin(key string) Origin {
+	// Arrays are leaves, the array items have the origin of the array.
+	segs := c.keyNorm.segments(parseKeyPath(key))
+	for i, seg := range segs {
+		if seg.isIndex {
+			segs = segs[:i]
+			break
+		}
+	}
+	return c.origins[formatKeyPath(segs)]
+}
+
+// Return every leaf key in lexical order with its resolved value and origin, one key per line, e.g.
+//
+//	foo.id: 123 # file app.yaml
+//	foo.name: foo1 # args
+func (c *OlayConfig) explain() string {
+	var keys []string
+	values := make(map[string]any)
+	walkLeaves("", c.resolvedTree(), func(key string, v any) {
+		if len(key) > 0 {
+			keys = append(keys, key)
+			values[key] = v
+		}
+	})
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		v := Value{values[key]}
+		s := v.String()
+		switch values[key].(type) {
+		case []any:
+			s = fmt.Sprintf("%v", values[key])
+		case nil:
+			s = "null"
+		}
+		fmt.Fprintf(&sb, "%v: %v # %v\n", key, s, c.leafOrigin(key))
+	}
+	return sb.String()
+}
+
+// Return the origin of the leaf key, or the origin of its nearest ancestor if the leaf has no origin,
+// e.g. the leaves of a sub-tree interpolated by '${other.key}' have the origin of the placeholder.
+func (c *OlayConfig) leafOrigin(key string) Origin {
+	segs := parseKeyPath(key)
+	for i := len(segs); i > 0; i-- {
+		if o, ok := c.origins[formatKeyPath(segs[:i])]; ok {
+			return o
+		}
+	}
+	return Origin{}
+}
@@ -0,0 +1,181 @@
+package olayc
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOrigin(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"app.yaml": `
+foo:
+  name: foo-yaml
+  id: 1
+  tags: [a, b]
+`,
+		"app.ini": `
+; comment
+[foo]
+name = foo-ini
+url = http://a.b
+
+[foo.redis]
+host = localhost
+`,
+		"app.properties": `
+# comment
+foo.redis.port = 6379
+`,
+		"app.env": `
+# comment
+FOO_DEBUG=true
+export FOO_LEVEL=3
+`,
+	})
+
+	c := New()
+	c.AddSource(ArgsSource([]string{"-foo.name=foo-args"}))
+	c.AddSource(YamlFileSource(filepath.Join(dir, "app.yaml")))
+	c.AddSource(IniFileSource(filepath.Join(dir, "app.ini")))
+	c.AddSource(PropertiesFileSource(filepath.Join(dir, "app.properties")))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.LoadDotenvFile(filepath.Join(dir, "app.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.LoadKVs([]KV{{"foo.x", 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect Origin
+	}{
+		{"foo.name", Origin{"args", "args", 0}},
+		{"foo.id", Origin{"file", filepath.Join(dir, "app.yaml"), 4}},
+		{"foo.tags", Origin{"file", filepath.Join(dir, "app.yaml"), 5}},
+		{"foo.url", Origin{"file", filepath.Join(dir, "app.ini"), 5}},
+		{"foo.redis.host", Origin{"file", filepath.Join(dir, "app.ini"), 8}},
+		{"foo.redis.port", Origin{"file", filepath.Join(dir, "app.properties"), 3}},
+		{"foo.debug", Origin{"file", filepath.Join(dir, "app.env"), 3}},
+		{"foo.level", Origin{"file", filepath.Join(dir, "app.env"), 4}},
+		{"foo.x", Origin{"kvs", "", 0}},
+		{"foo", Origin{}},
+		{"foo.notexist", Origin{}},
+	} {
+		got := c.Origin(test.key)
+		if got != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got, test.expect)
+		}
+	}
+}
+
+func TestOriginProfile(t *testing.T) {
+	var testdata = []byte(`
+[foo]
+name = foo
+id = 1
+
+[profiles.prod.foo]
+name = foo-prod
+`)
+	c := New()
+	err := c.LoadSource(ProfileSource(SourceWithPriority(IniSource(testdata), PriorityEnv), "prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect Origin
+	}{
+		{"foo.name", Origin{"bytes", "ini", 7}},
+		{"foo.id", Origin{"bytes", "ini", 4}},
+	} {
+		got := c.Origin(test.key)
+		if got != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got, test.expect)
+		}
+	}
+}
+
+func TestOriginString(t *testing.T) {
+	for i, test := range []struct {
+		origin Origin
+		expect string
+	}{
+		{Origin{"args", "args", 0}, "args"},
+		{Origin{"file", "app.yaml", 0}, "file app.yaml"},
+		{Origin{"file", "app.ini", 12}, "file app.ini:12"},
+		{Origin{"kvs", "", 0}, "kvs"},
+	} {
+		got := test.origin.String()
+		if got != test.expect {
+			t.Errorf("[%v] got(%v)!=expect(%v)\n", i, got, test.expect)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	c := New()
+	_, err := c.LoadArgs([]string{"-foo.name=foo-args"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadYaml([]byte(`
+foo:
+  name: foo-yaml
+  id: 1
+  tags: [a, b]
+  url: http://${foo.name}
+redis:
+  host: localhost
+cache: ${redis}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `cache.host: localhost # bytes yaml:9
+foo.id: 1 # bytes yaml:4
+foo.name: foo-args # args
+foo.tags: [a b] # bytes yaml:5
+foo.url: http://foo-args # bytes yaml:6
+redis.host: localhost # bytes yaml:8
+`
+	got := c.explain()
+	if got != expect {
+		t.Errorf("got(%v)!=expect(%v)\n", got, expect)
+	}
+}
+
+func TestYamlLines(t *testing.T) {
+	got := yamlLines([]byte(`
+base: &base
+  port: 80
+foo:
+  <<: *base
+  "app.name": foo
+  8080: http
+  servers:
+  - host: s0
+`))
+	expect := map[string]int{
+		"base":           2,
+		"base.port":      3,
+		"foo":            4,
+		`foo."app.name"`: 6,
+		"foo.8080":       7,
+		"foo.servers":    8,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got(%v)!=expect(%v)\n", got, expect)
+	}
+	if got := yamlLines([]byte("foo: [")); got != nil {
+		t.Errorf("got(%v) is not nil\n", got)
+	}
+}
@@ -0,0 +1,83 @@
+package olayc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ProfilesKey is the top-level key of in-file profile sections, e.g.
+	//
+	//	foo:
+	//	  name: foo
+	//	profiles:
+	//	  prod:
+	//	    foo:
+	//	      name: foo-prod
+	//
+	// With profile 'prod', the section 'profiles.prod' is overlayed above the file's own keys.
+	// The key is reserved, it's removed from the configure tree if the profile section is applied.
+	ProfilesKey = "profiles"
+
+	// profileEnv is the environment setting profile, `-oc.profile` is prior to it.
+	profileEnv = "OLAYC_PROFILE"
+)
+
+// profileSource is a Source applying in-file profile sections, refer to `ProfilesKey`.
+type profileSource struct {
+	Source
+	profile string
+}
+
+// ProfileSource returns a source applying in-file profile sections of the source, refer to `ProfilesKey`.
+// The profile sections are removed from the configure tree if the profile section is applied,
+// the tree is untouched if the profile is empty or the source has no section of the profile.
+func ProfileSource(src Source, profile string) Source {
+	return &profileSource{src, profile}
+}
+
+func (s *profileSource) Load() (map[any]any, error) {
+	m, err := s.Source.Load()
+	if err != nil {
+		return nil, err
+	}
+	return applyProfile(m, s.profile), nil
+}
+
+// Overlay the profile section above m, and remove the profile sections.
+// Return m untouched if the profile is empty or there is no section of the profile.
+func applyProfile(m map[any]any, profile string) map[any]any {
+	if len(profile) == 0 {
+		return m
+	}
+	profiles, ok := m[ProfilesKey].(map[any]any)
+	if !ok {
+		return m
+	}
+	section, ok := profiles[profile].(map[any]any)
+	if !ok {
+		return m
+	}
+	delete(m, ProfilesKey)
+	copyMap(section, m)
+	return section
+}
+
+// Return the profile variant of file, e.g. 'app.yaml' with profile 'prod' is 'app.prod.yaml'.
+func profileFile(name string, profile string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + profile + ext
+}
+
+// Return the profile variant of file if it exists, otherwise return "".
+func profileFileExists(name string, profile string) string {
+	if len(profile) == 0 || name == Stdin {
+		return ""
+	}
+	pf := profileFile(name, profile)
+	if fi, err := os.Stat(pf); err != nil || fi.IsDir() {
+		return ""
+	}
+	return pf
+}
@@ -0,0 +1,89 @@
+package olayc
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileFile(t *testing.T) {
+	for i, test := range []struct {
+		name    string
+		profile string
+		expect  string
+	}{
+		{"app.yaml", "prod", "app.prod.yaml"},
+		{"/etc/app/app.json", "staging", "/etc/app/app.staging.json"},
+		{"conf/app", "prod", "conf/app.prod"},
+	} {
+		got := profileFile(test.name, test.profile)
+		if got != test.expect {
+			t.Errorf("[%v] got(%v)!=expect(%v)\n", i, got, test.expect)
+		}
+	}
+}
+
+func TestProfileFileExists(t *testing.T) {
+	dir := createTestDir(t, map[string]string{
+		"app.yaml":      "",
+		"app.prod.yaml": "",
+	})
+	for i, test := range []struct {
+		name    string
+		profile string
+		expect  string
+	}{
+		{filepath.Join(dir, "app.yaml"), "prod", filepath.Join(dir, "app.prod.yaml")},
+		{filepath.Join(dir, "app.yaml"), "staging", ""},
+		{filepath.Join(dir, "app.yaml"), "", ""},
+		{Stdin, "prod", ""},
+	} {
+		got := profileFileExists(test.name, test.profile)
+		if got != test.expect {
+			t.Errorf("[%v] got(%v)!=expect(%v)\n", i, got, test.expect)
+		}
+	}
+}
+
+func TestProfileSource(t *testing.T) {
+	var testdata = []byte(`
+foo:
+  name: foo
+  id: 1
+profiles:
+  prod:
+    foo:
+      name: foo-prod
+      redis:
+        host: redis.prod
+  staging:
+    foo:
+      name: foo-staging
+`)
+
+	for i, test := range []struct {
+		profile string
+		key     string
+		expect  any
+	}{
+		{"prod", "foo.name", "foo-prod"},
+		{"prod", "foo.id", 1},
+		{"prod", "foo.redis.host", "redis.prod"},
+		{"prod", ProfilesKey, nil},
+		{"staging", "foo.name", "foo-staging"},
+		{"staging", "foo.redis.host", nil},
+		{"", "foo.name", "foo"},
+		{"", "profiles.prod.foo.name", "foo-prod"},
+		{"dev", "foo.name", "foo"},
+		{"dev", "profiles.staging.foo.name", "foo-staging"},
+	} {
+		var c = New()
+		err := c.LoadSource(ProfileSource(YamlSource(testdata), test.profile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] profile=%v key=%v, got(%v)!=expect(%v)\n", i, test.profile, test.key, got.v, test.expect)
+		}
+	}
+}
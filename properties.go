@@ -0,0 +1,177 @@
+package olayc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// propertiesParser parses java .properties file to kvs.
+// The supported syntax:
+//
+//	# Comment line
+//	! Comment line
+//	foo.name = foo1
+//	foo.id: 123
+//	foo.url http://www.example.com
+//	foo.msg = hello \
+//	          world
+//	foo.path\ name = C:\\foo\u0020bar
+//
+// The key and value are seperated by the first unescaped '=', ':' or whitespace.
+// Line ending with odd number of '\' is continued by the next line, leading whitespaces of the next line are trimmed.
+// Escapes \t \n \r \f \uXXXX are supported, other escaped characters are taken literally.
+// The keys are splitted by '.' to sub-trees. If a key is defined more than once, the last one wins.
+//
+// Value interpretation should refer to `interpret(string)`.
+type propertiesParser struct {
+	kvs   []KV
+	index map[string]int
+	lines map[string]int
+}
+
+// Parse properties bytes, return error with line number if syntax error.
+func (psr *propertiesParser) parse(data []byte) error {
+	psr.index = make(map[string]int)
+	psr.lines = make(map[string]int)
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineno := i + 1
+		line := strings.TrimLeft(strings.TrimRight(lines[i], "\r"), " \t\f")
+		if len(line) == 0 || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Join continued lines.
+		for isContinued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(strings.TrimRight(lines[i], "\r"), " \t\f")
+		}
+		if isContinued(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, value, err := splitProperty(line)
+		if err != nil {
+			return errors.Wrapf(err, "line %v", lineno)
+		}
+		if len(key) == 0 {
+			return errors.Errorf("line %v: empty key", lineno)
+		}
+		psr.add(key, interpret(value))
+		psr.lines[key] = lineno
+	}
+	return nil
+}
+
+// Add kv, replace the value if key exists.
+func (psr *propertiesParser) add(key string, value any) {
+	if i, ok := psr.index[key]; ok {
+		psr.kvs[i].value = value
+		return
+	}
+	psr.index[key] = len(psr.kvs)
+	psr.kvs = append(psr.kvs, KV{key, value})
+}
+
+// Return if line is ending with odd number of '\'.
+func isContinued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// Split line to unescaped key and value.
+func splitProperty(line string) (string, string, error) {
+	// Find the first unescaped seperator.
+	pos := len(line)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			pos = i
+			break
+		}
+	}
+	key := line[:pos]
+
+	// Skip whitespaces around the seperator, and at most one '=' or ':'.
+	rest := strings.TrimLeft(line[pos:], " \t\f")
+	if len(rest) > 0 && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	key, err := unescapeProperty(key)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// Unescape properties string.
+func unescapeProperty(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i == len(s)-1 {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", errors.Errorf("invalid unicode escape: %v", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", errors.Errorf("invalid unicode escape: %v", s[i-1:i+5])
+			}
+			sb.WriteRune(rune(r))
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// Decode properties bytes.
+func decodeProperties(data []byte) (map[any]any, error) {
+	psr := &propertiesParser{}
+	err := psr.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return kvsToMap(psr.kvs), nil
+}
+
+// Return line numbers of properties keys, return nil if syntax error.
+func propertiesLines(data []byte) map[string]int {
+	psr := &propertiesParser{}
+	if psr.parse(data) != nil {
+		return nil
+	}
+	return psr.lines
+}
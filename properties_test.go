@@ -0,0 +1,67 @@
+package olayc
+
+import (
+	"testing"
+)
+
+func TestPropertiesParser(t *testing.T) {
+	var testdata = []byte(`
+# Comment line
+! Comment line
+foo.name = foo1
+foo.id: 123
+foo.url http://www.example.com
+foo.pi=3.1415926
+foo.onoff = true
+foo.msg = hello \
+          world
+foo.path\ name = C:\\foo\u0020bar
+foo.tab = a\tb
+foo.name = foo2
+`)
+
+	psr := &propertiesParser{}
+	err := psr.parse(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got = psr.kvs
+	var expect = []KV{
+		{"foo.name", "foo2"},
+		{"foo.id", uint64(123)},
+		{"foo.url", "http://www.example.com"},
+		{"foo.pi", float64(3.1415926)},
+		{"foo.onoff", true},
+		{"foo.msg", "hello world"},
+		{"foo.path name", "C:\\foo bar"},
+		{"foo.tab", "a\tb"},
+	}
+	if len(expect) != len(got) {
+		t.Logf("expect(len %v) %v\n", len(expect), expect)
+		t.Logf("got(len %v) %v\n", len(got), got)
+		t.FailNow()
+	}
+	for i := range expect {
+		if expect[i] != got[i] {
+			t.Errorf("[%v] expect(%q) != got(%q)", i, expect[i], got[i])
+		}
+	}
+}
+
+func TestConfigGetScalarLoadProperties(t *testing.T) {
+	var c = New()
+	err := c.LoadProperties([]byte(`
+foo.name=foo1
+foo.redis.port=6380
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo1" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo1")
+	}
+	if got := c.Int("foo.redis.port", 0); got != 6380 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 6380)
+	}
+}
@@ -0,0 +1,158 @@
+package olayc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// QueryResult is a key matched by `Query()`.
+type QueryResult struct {
+	Key   string
+	Value Value
+}
+
+// Wildcards of query pattern.
+const (
+	wildcardOne = "*"
+	wildcardAny = "**"
+)
+
+// Query the keys matching the pattern, the pattern is a key path with wildcards:
+//
+//	*.redis.host      # '*' matches exactly one segment, a map key or an array index
+//	servers[*].host   # '[*]' is the same as '.*'
+//	**.host           # '**' matches any number of segments, including zero
+//
+// Both leaves and sub-trees are matched. The results are sorted by key path,
+// the map keys are in lexical order, the integer keys and array indexes are in numeric order.
+func (c *OlayConfig) Query(pattern string) []QueryResult {
+	pat := c.keyNorm.segments(parseKeyPath(strings.ReplaceAll(pattern, "[*]", "."+wildcardOne)))
+	q := query{seen: make(map[string]bool)}
+	q.match(c.resolvedTree(), "", nil, pat)
+	sort.Slice(q.matches, func(i, j int) bool {
+		if c := comparePaths(q.matches[i].path, q.matches[j].path); c != 0 {
+			return c < 0
+		}
+		return q.matches[i].result.Key < q.matches[j].result.Key
+	})
+	results := make([]QueryResult, len(q.matches))
+	for i, m := range q.matches {
+		results[i] = m.result
+	}
+	return results
+}
+
+// query is the state of matching a pattern.
+type query struct {
+	matches []queryMatch
+	seen    map[string]bool
+}
+
+// queryMatch is a matched key with its path segments for sorting.
+type queryMatch struct {
+	path   []pathSegment
+	result QueryResult
+}
+
+// Match the node at key path against the rest of pattern.
+func (q *query) match(node any, key string, path []pathSegment, pat []pathSegment) {
+	if len(pat) == 0 {
+		// The root is not matched, e.g. by '**'.
+		if len(path) == 0 || q.seen[key] {
+			return
+		}
+		q.seen[key] = true
+		v := Value{node}
+		if node == nil {
+			v = Value{null}
+		}
+		q.matches = append(q.matches, queryMatch{path, QueryResult{key, v}})
+		return
+	}
+
+	seg := pat[0]
+	if isWildcard(seg, wildcardAny) {
+		q.match(node, key, path, pat[1:])
+		eachChild(node, key, path, func(child any, key string, path []pathSegment) {
+			q.match(child, key, path, pat)
+		})
+		return
+	}
+	eachChild(node, key, path, func(child any, key string, path []pathSegment) {
+		if matchSegment(seg, path[len(path)-1]) {
+			q.match(child, key, path, pat[1:])
+		}
+	})
+}
+
+// Call fn for each child of map or array node, with the key path of child.
+func eachChild(node any, key string, path []pathSegment, fn func(child any, key string, path []pathSegment)) {
+	switch x := node.(type) {
+	case map[any]any:
+		for k, child := range x {
+			fn(child, joinKey(key, k), appendSegment(path, pathSegment{key: k}))
+		}
+	case []any:
+		for i, child := range x {
+			fn(child, fmt.Sprintf("%v[%v]", key, i), appendSegment(path, pathSegment{index: i, isIndex: true}))
+		}
+	}
+}
+
+// Return a new path with seg appended, the path is not shared between the children.
+func appendSegment(path []pathSegment, seg pathSegment) []pathSegment {
+	p := make([]pathSegment, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, seg)
+}
+
+// Return if the pattern segment is the wildcard.
+func isWildcard(seg pathSegment, wildcard string) bool {
+	return !seg.isIndex && seg.key == wildcard
+}
+
+// Return if the pattern segment matches the path segment.
+func matchSegment(pat, seg pathSegment) bool {
+	if isWildcard(pat, wildcardOne) {
+		return true
+	}
+	if pat.isIndex || seg.isIndex {
+		return pat.isIndex == seg.isIndex && pat.index == seg.index
+	}
+	return fmt.Sprint(pat.key) == fmt.Sprint(seg.key)
+}
+
+// Compare key paths segment by segment, a path is less than the longer paths it prefixes.
+func comparePaths(a, b []pathSegment) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareSegments(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// Compare path segments, array indexes and integer keys are less than string keys.
+func compareSegments(a, b pathSegment) int {
+	an, aok := segmentNumber(a)
+	bn, bok := segmentNumber(b)
+	switch {
+	case aok && bok:
+		return an - bn
+	case aok:
+		return -1
+	case bok:
+		return 1
+	}
+	return strings.Compare(fmt.Sprint(a.key), fmt.Sprint(b.key))
+}
+
+// Return the number of array index or integer map key.
+func segmentNumber(seg pathSegment) (int, bool) {
+	if seg.isIndex {
+		return seg.index, true
+	}
+	n, ok := seg.key.(int)
+	return n, ok
+}
@@ -0,0 +1,72 @@
+package olayc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	c := New()
+	err := c.LoadYaml([]byte(`
+tenants:
+  foo:
+    redis:
+      host: redis.foo
+      port: 6379
+  bar:
+    redis:
+      host: redis.bar
+    mysql:
+      host: mysql.bar
+  baz:
+    redis: !delete
+servers:
+- host: s0
+- host: s1
+ports:
+  10: ten
+  9: nine
+host: localhost
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		pattern string
+		keys    []string
+		values  []any
+	}{
+		{"tenants.*.redis.host", []string{"tenants.bar.redis.host", "tenants.foo.redis.host"}, []any{"redis.bar", "redis.foo"}},
+		{"tenants.*.*.host", []string{"tenants.bar.mysql.host", "tenants.bar.redis.host", "tenants.foo.redis.host"}, []any{"mysql.bar", "redis.bar", "redis.foo"}},
+		{"servers[*].host", []string{"servers[0].host", "servers[1].host"}, []any{"s0", "s1"}},
+		{"servers.*.host", []string{"servers[0].host", "servers[1].host"}, []any{"s0", "s1"}},
+		{"servers[1].*", []string{"servers[1].host"}, []any{"s1"}},
+		{"ports.*", []string{"ports.9", "ports.10"}, []any{"nine", "ten"}},
+		{"tenants.baz.*", nil, nil},
+		{"nothing.*", nil, nil},
+		{"**.host", []string{
+			"host",
+			"servers[0].host",
+			"servers[1].host",
+			"tenants.bar.mysql.host",
+			"tenants.bar.redis.host",
+			"tenants.foo.redis.host",
+		}, []any{"localhost", "s0", "s1", "mysql.bar", "redis.bar", "redis.foo"}},
+		{"tenants.foo.**", []string{"tenants.foo", "tenants.foo.redis", "tenants.foo.redis.host", "tenants.foo.redis.port"}, nil},
+	} {
+		results := c.Query(test.pattern)
+		var keys []string
+		var values []any
+		for _, r := range results {
+			keys = append(keys, r.Key)
+			values = append(values, r.Value.v)
+		}
+		if !reflect.DeepEqual(keys, test.keys) {
+			t.Errorf("[%v] pattern(%v) got(%v)!=expect(%v)\n", i, test.pattern, keys, test.keys)
+		}
+		if test.values != nil && !reflect.DeepEqual(values, test.values) {
+			t.Errorf("[%v] pattern(%v) got(%v)!=expect(%v)\n", i, test.pattern, values, test.values)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package olayc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Built-in resolver schemes, the resolvers are not registered by default, e.g.
+//
+//	olayc.Load(olayc.WithResolver(olayc.EnvScheme, olayc.EnvResolver()))
+//
+// They're opt-in, since the placeholders in any source are resolved, including the remote sources.
+const (
+	EnvScheme  = "env"
+	FileScheme = "file"
+)
+
+// Resolver resolves the placeholders with its scheme, e.g. '${env:DB_PASSWORD}' is resolved by the resolver of scheme 'env'
+// with the reference 'DB_PASSWORD'. Return false if the reference is not found, then the fallback is used, e.g. '${env:DB_USER:-root}'.
+type Resolver interface {
+	Resolve(ref string) (any, bool, error)
+}
+
+// ResolverFunc is an adapter to use ordinary function as Resolver.
+type ResolverFunc func(ref string) (any, bool, error)
+
+// Resolve calls f(ref).
+func (f ResolverFunc) Resolve(ref string) (any, bool, error) {
+	return f(ref)
+}
+
+// EnvResolver returns a Resolver resolving environment variables, e.g. '${env:DB_PASSWORD}'.
+// The value is a string, it's not interpreted.
+func EnvResolver() Resolver {
+	return ResolverFunc(func(ref string) (any, bool, error) {
+		v, ok := os.LookupEnv(ref)
+		return v, ok, nil
+	})
+}
+
+// FileResolver returns a Resolver resolving file contents, e.g. '${file:/run/secrets/db}'.
+// The value is a string with the trailing newline trimmed, the relative path is relative to the working directory.
+func FileResolver() Resolver {
+	return ResolverFunc(func(ref string) (any, bool, error) {
+		data, err := os.ReadFile(ref)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	})
+}
+
+// RegisterResolver registers the resolver of scheme, e.g. 'vault' resolves '${vault:secret/db#password}'.
+// It replaces the resolver of the same scheme. The built-in 'env' and 'file' resolvers are not registered by default, refer to `EnvScheme`.
+// The placeholder without registered scheme references a key, refer to `Resolve()`.
+func (c *OlayConfig) RegisterResolver(scheme string, r Resolver) {
+	c.resolvers[scheme] = r
+	c.staleResolved()
+}
+
+// Return the resolver and the reference of the placeholder reference with scheme, e.g. 'env:DB_PASSWORD'.
+// Return nil if the scheme is not registered.
+func (c *OlayConfig) resolverOf(ref string) (Resolver, string) {
+	scheme, rest, ok := strings.Cut(ref, ":")
+	if !ok {
+		return nil, ref
+	}
+	r, ok := c.resolvers[scheme]
+	if !ok {
+		return nil, ref
+	}
+	return r, rest
+}
+
+// ResolveError is the error of resolving a placeholder, with the key path and the origin of the value containing the placeholder.
+type ResolveError struct {
+	Key    string
+	Origin Origin
+	Err    error
+}
+
+// Error returns the error message, e.g. "interpolate db.password (file app.yaml:3): reference '${nothing}' not found".
+func (e *ResolveError) Error() string {
+	if len(e.Origin.Kind) == 0 {
+		return fmt.Sprintf("interpolate %v: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("interpolate %v (%v): %v", e.Key, e.Origin, e.Err)
+}
+
+// Cause returns the underlying error, for `errors.Cause()`.
+func (e *ResolveError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying error.
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// Resolve the placeholder reference with the resolver, the results are cached in a resolving pass.
+func (ip *interpolator) resolveScheme(r Resolver, ref string, raw string) (any, bool, error) {
+	if res, ok := ip.schemes[raw]; ok {
+		return res.v, res.ok, res.err
+	}
+	v, ok, err := r.Resolve(ref)
+	if err != nil {
+		err = errors.Wrapf(err, "resolve '${%v}'", raw)
+	}
+	ip.schemes[raw] = schemeResult{v, ok, err}
+	return v, ok, err
+}
+
+// schemeResult is the cached result of a resolver.
+type schemeResult struct {
+	v   any
+	ok  bool
+	err error
+}
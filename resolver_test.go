@@ -0,0 +1,175 @@
+package olayc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestResolver(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	err := os.WriteFile(secret, []byte("s3cret\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OLAYC_TEST_DB_USER", "admin")
+
+	c := New()
+	c.RegisterResolver(EnvScheme, EnvResolver())
+	c.RegisterResolver(FileScheme, FileResolver())
+	c.RegisterResolver("vault", ResolverFunc(func(ref string) (any, bool, error) {
+		if ref == "secret/db#port" {
+			return 5432, true, nil
+		}
+		return nil, false, nil
+	}))
+	err = c.LoadYaml([]byte(`
+db:
+  user: ${env:OLAYC_TEST_DB_USER}
+  password: ${file:` + secret + `}
+  port: ${vault:secret/db#port}
+  host: ${env:OLAYC_TEST_DB_HOST:-localhost}
+  token: ${file:` + filepath.Join(dir, "nothing") + `:-none}
+  dsn: ${db.user}:${db.password}@${db.host}:${db.port}
+  url: ${unknown:scheme}
+"unknown:scheme": key
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"db.user", "admin"},
+		{"db.password", "s3cret"},
+		{"db.port", 5432},
+		{"db.host", "localhost"},
+		{"db.token", "none"},
+		{"db.dsn", "admin:s3cret@localhost:5432"},
+		{"db.url", "key"},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+}
+
+func TestResolverError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	err := os.WriteFile(path, []byte("db:\n  password: ${env:OLAYC_TEST_NOTHING}\n  token: ${vault:db}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultErr := errors.New("vault sealed")
+
+	c := New()
+	c.RegisterResolver(EnvScheme, EnvResolver())
+	c.RegisterResolver("vault", ResolverFunc(func(ref string) (any, bool, error) {
+		return nil, false, vaultErr
+	}))
+	c.AddSource(YamlFileSource(path))
+	err = c.LoadSources()
+	re, ok := err.(*ResolveError)
+	if !ok {
+		t.Fatalf("got(%v) is not ResolveError\n", err)
+	}
+	if re.Key != "db.password" || re.Origin.Name != path || re.Origin.Kind != "file" {
+		t.Errorf("got(%+v)\n", re)
+	}
+	if !strings.Contains(err.Error(), "(file "+path+":2)") || !strings.Contains(err.Error(), "'${env:OLAYC_TEST_NOTHING}' not found") {
+		t.Errorf("got(%v)\n", err)
+	}
+
+	c.RegisterResolver(EnvScheme, ResolverFunc(func(ref string) (any, bool, error) {
+		return "env", true, nil
+	}))
+	err = c.Resolve()
+	if errors.Cause(err) != vaultErr {
+		t.Errorf("got(%v)!=expect(%v)\n", err, vaultErr)
+	}
+	if got := c.String("db.password", ""); got != "env" {
+		t.Errorf("got(%v)\n", got)
+	}
+}
+
+func TestResolverNotRegistered(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	err := os.WriteFile(secret, []byte("s3cret\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OLAYC_TEST_DB_USER", "admin")
+
+	// The built-in resolvers are opt-in, the placeholders are references of keys.
+	c := New()
+	c.AddSource(YamlSource([]byte(`
+db:
+  user: ${env:OLAYC_TEST_DB_USER}
+  password: ${file:` + secret + `}
+`)))
+	err = c.LoadSources()
+	if err == nil || !strings.Contains(err.Error(), "'${file:"+secret+"}' not found") {
+		t.Errorf("got(%v)\n", err)
+	}
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"db.user", "${env:OLAYC_TEST_DB_USER}"},
+		{"db.password", "${file:" + secret + "}"},
+	} {
+		got := c.Get(test.key)
+		if got.v != test.expect {
+			t.Errorf("[%v] key(%v) got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+}
+
+func TestResolverCalls(t *testing.T) {
+	calls := 0
+	c := New()
+	c.RegisterResolver("vault", ResolverFunc(func(ref string) (any, bool, error) {
+		calls++
+		return "s3cret", true, nil
+	}))
+	c.AddSource(YamlSource([]byte("db:\n  password: ${vault:secret/db}\n")))
+	for i := 0; i < 5; i++ {
+		c.AddSource(YamlSource([]byte(fmt.Sprintf("key%v: %v\n", i, i))))
+	}
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if got := c.String("db.password", ""); got != "s3cret" {
+			t.Errorf("got(%v)\n", got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("got(%v)!=expect(%v) calls\n", calls, 1)
+	}
+
+	// Resolved again on demand after more sources are loaded.
+	err = c.LoadYaml([]byte("key5: 5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Get("db.password")
+	c.Get("key5")
+	if calls != 2 {
+		t.Errorf("got(%v)!=expect(%v) calls\n", calls, 2)
+	}
+}
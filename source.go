@@ -0,0 +1,381 @@
+package olayc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Default priorities of the built-in sources.
+// The source with higher priority is overlayed above the lower ones,
+// thus the fixed priority is args > env > files.
+const (
+	PriorityDefaults = 0
+	PriorityFile     = 100
+	PriorityEnv      = 200
+	PriorityArgs     = 300
+)
+
+// Source is a configure source which is loaded as one layer of OlayConfig.
+type Source interface {
+	// Name of the source, e.g. the file name, used in messages.
+	Name() string
+	// Priority of the source, the higher one is overlayed above the lower ones.
+	Priority() int
+	// Load the source and return the configure tree.
+	Load() (map[any]any, error)
+}
+
+// decodeFunc decodes bytes of a specific format to configure tree.
+type decodeFunc func(data []byte) (map[any]any, error)
+
+// decoders are the supported file formats, keyed by format name.
+var decoders = map[string]decodeFunc{
+	"yaml":       decodeYaml,
+	"json":       decodeJson,
+	"toml":       decodeToml,
+	"dotenv":     decodeDotenv,
+	"ini":        decodeIni,
+	"properties": decodeProperties,
+}
+
+// formats are the file formats keyed by file extension.
+var formats = map[string]string{
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".json":       "json",
+	".toml":       "toml",
+	".ini":        "ini",
+	".properties": "properties",
+	".env":        "dotenv",
+}
+
+// Return format of the file by extension, return "" if unknown.
+func formatOf(name string) string {
+	return formats[strings.ToLower(path.Ext(name))]
+}
+
+// Decode yaml bytes.
+func decodeYaml(data []byte) (map[any]any, error) {
+	var m = make(map[any]any)
+	err := yaml.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	err = applyYamlDeletes(data, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Decode json bytes.
+// To unmarshal a JSON object into a map using the standard library "encoding/json",
+// the map's key type must either be any string type, an integer.
+// Thus, the unmarshal map type is map[string]any(and all sub-maps), it not compatible with `copyMap()` which is accepting type map[any]any.
+// We must convert `map[string]any` to `map[any]any`, this is simplily done by marshal/unmarshal with "gopkg.in/yaml.v2".
+func decodeJson(data []byte) (map[any]any, error) {
+	var m = make(map[string]any)
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	return convertMap(m)
+}
+
+// Decode bytes with the given format.
+func decode(format string, data []byte) (map[any]any, error) {
+	fn, ok := decoders[format]
+	if !ok {
+		return nil, errors.Errorf("unknown format: %v", format)
+	}
+	return fn(data)
+}
+
+// bytesSource is a Source decoding bytes with a specific format.
+type bytesSource struct {
+	name     string
+	priority int
+	format   string
+	data     []byte
+}
+
+func (s *bytesSource) Name() string  { return s.name }
+func (s *bytesSource) Priority() int { return s.priority }
+func (s *bytesSource) Load() (map[any]any, error) {
+	return decode(s.format, s.data)
+}
+
+// Stdin is the file path reading from standard input.
+const Stdin = "-"
+
+// fileSource is a Source reading a file with a specific format, the include directives are resolved.
+// If the path is `Stdin`, it reads from standard input.
+type fileSource struct {
+	path     string
+	priority int
+	format   string
+	lines    map[string]int
+	files    map[string]string
+}
+
+func (s *fileSource) Name() string {
+	if s.path == Stdin {
+		return "stdin"
+	}
+	return s.path
+}
+func (s *fileSource) Priority() int { return s.priority }
+func (s *fileSource) Load() (map[any]any, error) {
+	if s.path != Stdin {
+		f, err := (&includer{}).load(s.path, s.format)
+		if err != nil {
+			return nil, err
+		}
+		s.lines, s.files = f.lines, f.files
+		return f.m, nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decode(s.format, data)
+	if err != nil {
+		return nil, err
+	}
+	// Included files are relative to the working directory.
+	f := &includedFile{m: m, lines: decodeLines(s.format, data)}
+	err = (&includer{}).include(s.path, s.format, f)
+	if err != nil {
+		return nil, err
+	}
+	s.lines, s.files = f.lines, f.files
+	return f.m, nil
+}
+
+// readerSource is a Source reading from io.Reader with a specific format.
+type readerSource struct {
+	r        io.Reader
+	priority int
+	format   string
+	lines    map[string]int
+}
+
+func (s *readerSource) Name() string  { return s.format + " reader" }
+func (s *readerSource) Priority() int { return s.priority }
+func (s *readerSource) Load() (map[any]any, error) {
+	data, err := io.ReadAll(s.r)
+	if err != nil {
+		return nil, err
+	}
+	s.lines = decodeLines(s.format, data)
+	return decode(s.format, data)
+}
+
+// fsSource is a Source reading a file from fs.FS, the format is detected by extension.
+type fsSource struct {
+	fsys     fs.FS
+	path     string
+	priority int
+	lines    map[string]int
+	files    map[string]string
+}
+
+func (s *fsSource) Name() string  { return s.path }
+func (s *fsSource) Priority() int { return s.priority }
+func (s *fsSource) Load() (map[any]any, error) {
+	format := formatOf(s.path)
+	if len(format) == 0 {
+		return nil, errors.Errorf("unknown format of file: %v", s.path)
+	}
+	f, err := (&includer{fsys: s.fsys}).load(s.path, format)
+	if err != nil {
+		return nil, err
+	}
+	s.lines, s.files = f.lines, f.files
+	return f.m, nil
+}
+
+// kvsSource is a Source building configure tree from key-value pairs.
+type kvsSource struct {
+	name     string
+	priority int
+	kvs      []KV
+}
+
+func (s *kvsSource) Name() string  { return s.name }
+func (s *kvsSource) Priority() int { return s.priority }
+func (s *kvsSource) Load() (map[any]any, error) {
+	return kvsToMap(s.kvs), nil
+}
+
+// YamlSource returns a Source loading yaml bytes.
+func YamlSource(data []byte) Source {
+	return &bytesSource{"yaml", PriorityFile, "yaml", data}
+}
+
+// YamlFileSource returns a Source loading yaml file.
+func YamlFileSource(filepath string) Source {
+	return &fileSource{filepath, PriorityFile, "yaml", nil, nil}
+}
+
+// JsonSource returns a Source loading json bytes.
+func JsonSource(data []byte) Source {
+	return &bytesSource{"json", PriorityFile, "json", data}
+}
+
+// JsonFileSource returns a Source loading json file.
+func JsonFileSource(filepath string) Source {
+	return &fileSource{filepath, PriorityFile, "json", nil, nil}
+}
+
+// TomlSource returns a Source loading toml bytes.
+func TomlSource(data []byte) Source {
+	return &bytesSource{"toml", PriorityFile, "toml", data}
+}
+
+// TomlFileSource returns a Source loading toml file.
+func TomlFileSource(filepath string) Source {
+	return &fileSource{filepath, PriorityFile, "toml", nil, nil}
+}
+
+// IniSource returns a Source loading ini bytes.
+func IniSource(data []byte) Source {
+	return &bytesSource{"ini", PriorityFile, "ini", data}
+}
+
+// IniFileSource returns a Source loading ini file.
+func IniFileSource(filepath string) Source {
+	return &fileSource{filepath, PriorityFile, "ini", nil, nil}
+}
+
+// PropertiesSource returns a Source loading java properties bytes.
+func PropertiesSource(data []byte) Source {
+	return &bytesSource{"properties", PriorityFile, "properties", data}
+}
+
+// PropertiesFileSource returns a Source loading java .properties file.
+func PropertiesFileSource(filepath string) Source {
+	return &fileSource{filepath, PriorityFile, "properties", nil, nil}
+}
+
+// DotenvSource returns a Source loading dotenv bytes, it has the same priority as environments.
+func DotenvSource(data []byte) Source {
+	return &bytesSource{"dotenv", PriorityEnv, "dotenv", data}
+}
+
+// DotenvFileSource returns a Source loading dotenv(.env) file, it has the same priority as environments.
+func DotenvFileSource(filepath string) Source {
+	return &fileSource{filepath, PriorityEnv, "dotenv", nil, nil}
+}
+
+// ReaderSource returns a Source loading from io.Reader with the format,
+// which is one of "yaml", "json", "toml", "ini", "properties" and "dotenv".
+func ReaderSource(r io.Reader, format string) Source {
+	return &readerSource{r, PriorityFile, format, nil}
+}
+
+// FSSource returns a Source loading file from fs.FS, e.g. embed.FS.
+// The format is detected by the file extension, refer to `OlayConfig.LoadFS()`.
+func FSSource(fsys fs.FS, path string) Source {
+	return &fsSource{fsys, path, PriorityFile, nil, nil}
+}
+
+// ArgsSource returns a Source loading commandline arguments.
+// The internal olayc flags which prefix with `-oc.|--oc.` are ignored, except that `-oc.unset=foo.bar` deletes the key 'foo.bar'.
+func ArgsSource(args []string) Source {
+	return newArgsSource(args)
+}
+
+// EnvsSource returns a Source loading environments, refer to `LoadEnvs()`.
+func EnvsSource(envs []string) Source {
+	return newEnvsSource(envs)
+}
+
+func newArgsSource(args []string) *kvsSource {
+	var kvs []KV
+	psr := &flagParser{}
+	psr.parse(args)
+	for _, kv := range psr.kvs {
+		// The keys unset by `-oc.unset` are deleted.
+		if internalFlags["unset"].is(kv.key) && kv.value != nil {
+			kvs = append(kvs, KV{fmt.Sprint(kv.value), Delete})
+			continue
+		}
+		if strings.HasPrefix(kv.key, internalFlagPrefix) {
+			continue
+		}
+		kvs = append(kvs, kv)
+	}
+	return &kvsSource{"args", PriorityArgs, kvs}
+}
+
+// PrefixedEnvsSource returns a Source loading environments with the prefix and the nesting separator, refer to `LoadPrefixedEnvs()`.
+func PrefixedEnvsSource(envs []string, prefix string, separator string) Source {
+	return newPrefixedEnvsSource(envs, prefix, separator)
+}
+
+func newEnvsSource(envs []string) *kvsSource {
+	return newPrefixedEnvsSource(envs, "", "")
+}
+
+func newPrefixedEnvsSource(envs []string, prefix string, separator string) *kvsSource {
+	psr := &envParser{prefix: prefix, separator: separator}
+	psr.parse(envs)
+	return &kvsSource{"envs", PriorityEnv, psr.kvs}
+}
+
+// KVsSource returns a Source loading key-value pairs, refer to `LoadKVs()`.
+func KVsSource(name string, priority int, kvs []KV) Source {
+	return &kvsSource{name, priority, kvs}
+}
+
+// prioritySource is a Source overriding the priority of another source.
+type prioritySource struct {
+	Source
+	priority int
+}
+
+func (s *prioritySource) Priority() int { return s.priority }
+
+// SourceWithPriority returns the source with overridden priority,
+// e.g. `SourceWithPriority(YamlFileSource("ops.yaml"), PriorityEnv+1)` makes the file overlayed above environments.
+func SourceWithPriority(src Source, priority int) Source {
+	if ps, ok := src.(*prioritySource); ok {
+		src = ps.Source
+	}
+	return &prioritySource{src, priority}
+}
+
+// Return if the source name matches the pattern.
+// It matches if the name equals to the pattern, or the name is a path ending with the pattern,
+// e.g. 'args' matches 'args', 'ops.yaml' matches '/etc/app/ops.yaml'.
+func matchSourceName(name string, pattern string) bool {
+	return name == pattern || strings.HasSuffix(name, "/"+pattern)
+}
+
+// Build configure tree from key-value pairs, the keys are key paths, refer to `parseKeyPath()`.
+// The previously key is more prior than the latter ones, refer to `LoadKVs()`.
+func kvsToMap(kvs []KV) map[any]any {
+	var m = make(map[any]any)
+	for _, kv := range kvs {
+		setPath(m, parseKeyPath(kv.key), kv.value)
+	}
+	return m
+}
+
+// Sort sources by priority from high to low.
+// Sources with the same priority keep the order as they are added.
+func sortSources(srcs []Source) {
+	sort.SliceStable(srcs, func(i, j int) bool {
+		return srcs[i].Priority() > srcs[j].Priority()
+	})
+}
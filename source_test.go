@@ -0,0 +1,239 @@
+package olayc
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testSource is a Source returning a fixed configure tree.
+type testSource struct {
+	name     string
+	priority int
+	m        map[any]any
+}
+
+func (s *testSource) Name() string               { return s.name }
+func (s *testSource) Priority() int              { return s.priority }
+func (s *testSource) Load() (map[any]any, error) { return s.m, nil }
+
+func TestSourceLoadSourcesPriority(t *testing.T) {
+	var c = New()
+	c.AddSource(YamlSource([]byte(`
+foo:
+  name: foo-yaml
+  id: 1
+  url: http://www.example.com
+`)))
+	c.AddSource(ArgsSource([]string{"-foo.name=foo-args", "-oc.v"}))
+	c.AddSource(EnvsSource([]string{"FOO_NAME=foo-env", "FOO_ID=2"}))
+	c.AddSource(&testSource{"custom", PriorityEnv + 1, map[any]any{
+		"foo": map[any]any{
+			"id":    3,
+			"label": "custom",
+		},
+	}})
+	c.AddSource(&testSource{"custom-low", 0, map[any]any{
+		"foo": map[any]any{
+			"label": "custom-low",
+			"url":   "http://www.default.com",
+		},
+	}})
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"foo.name", "foo-args"},
+		{"foo.id", "3"},
+		{"foo.label", "custom"},
+		{"foo.url", "http://www.example.com"},
+		{"oc.v", ""},
+	} {
+		got := c.String(test.key, "")
+		if got != test.expect {
+			t.Errorf("[%v] key=%v, got(\"%v\")!=expect(\"%v\")\n", i, test.key, got, test.expect)
+		}
+	}
+}
+
+func TestSourceSamePriorityOrder(t *testing.T) {
+	var c = New()
+	c.AddSource(YamlSource([]byte(`foo: {name: foo1}`)))
+	c.AddSource(JsonSource([]byte(`{"foo": {"name": "foo2", "id": 2}}`)))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo1" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo1")
+	}
+	if got := c.Int("foo.id", 0); got != 2 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 2)
+	}
+}
+
+func TestSourceLoadFail(t *testing.T) {
+	var c = New()
+	c.AddSource(YamlFileSource("./testdata/not-exist.yaml"))
+	err := c.LoadSources()
+	if err == nil {
+		t.Fatal("expect error loading not exist file")
+	}
+}
+
+func TestConfigLoadFS(t *testing.T) {
+	var fsys = fstest.MapFS{
+		"conf/foo.yml":        {Data: []byte("foo: {name: foo-yaml}")},
+		"conf/foo.json":       {Data: []byte(`{"foo": {"name": "foo-json", "id": 1}}`)},
+		"conf/foo.toml":       {Data: []byte("[foo]\nurl = \"http://www.example.com\"")},
+		"conf/foo.properties": {Data: []byte("foo.redis.host=localhost")},
+		"conf/foo.txt":        {Data: []byte("foo")},
+	}
+
+	var c = New()
+	for _, name := range []string{"conf/foo.yml", "conf/foo.json", "conf/foo.toml", "conf/foo.properties"} {
+		err := c.LoadFS(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"foo.name", "foo-yaml"},
+		{"foo.id", "1"},
+		{"foo.url", "http://www.example.com"},
+		{"foo.redis.host", "localhost"},
+	} {
+		got := c.String(test.key, "")
+		if got != test.expect {
+			t.Errorf("[%v] key=%v, got(\"%v\")!=expect(\"%v\")\n", i, test.key, got, test.expect)
+		}
+	}
+
+	if err := c.LoadFS(fsys, "conf/foo.txt"); err == nil {
+		t.Error("expect error loading unknown format")
+	}
+	if err := c.LoadFS(fsys, "conf/not-exist.yaml"); err == nil {
+		t.Error("expect error loading not exist file")
+	}
+}
+
+func TestSourceDefaultsFSPriority(t *testing.T) {
+	var fsys = fstest.MapFS{
+		"defaults.yaml": {Data: []byte("foo: {name: foo-default, url: http://www.default.com}")},
+	}
+
+	var c = New()
+	c.AddSource(&fsSource{fsys, "defaults.yaml", PriorityDefaults, nil, nil})
+	c.AddSource(YamlFileSource("./testdata/test1.yaml"))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo1" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo1")
+	}
+	if got := c.String("foo.url", ""); got != "http://www.default.com" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "http://www.default.com")
+	}
+}
+
+func TestConfigLoadReader(t *testing.T) {
+	var c = New()
+	err := c.LoadReader(strings.NewReader("foo: {name: foo-reader}"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadReader(strings.NewReader(`{"foo": {"id": 1}}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-reader" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-reader")
+	}
+	if got := c.Int("foo.id", 0); got != 1 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 1)
+	}
+
+	err = c.LoadReader(strings.NewReader("foo"), "unknown")
+	if err == nil {
+		t.Error("expect error loading unknown format")
+	}
+}
+
+func TestSourceFileStdin(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = f.WriteString("foo: {name: foo-stdin}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Seek(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	var c = New()
+	src := YamlFileSource(Stdin)
+	if src.Name() != "stdin" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", src.Name(), "stdin")
+	}
+	err = c.LoadSource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-stdin" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-stdin")
+	}
+}
+
+func TestSourceWithPriority(t *testing.T) {
+	var c = New()
+	c.AddSource(EnvsSource([]string{"FOO_NAME=foo-env", "FOO_ID=1"}))
+	c.AddSource(SourceWithPriority(YamlSource([]byte("foo: {name: foo-ops}")), PriorityEnv+1))
+	c.AddSource(SourceWithPriority(SourceWithPriority(ArgsSource([]string{"-foo.id=2"}), 0), PriorityDefaults))
+	err := c.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("foo.name", ""); got != "foo-ops" {
+		t.Errorf("got(\"%v\")!=expect(\"%v\")\n", got, "foo-ops")
+	}
+	if got := c.Int("foo.id", 0); got != 1 {
+		t.Errorf("got(%v)!=expect(%v)\n", got, 1)
+	}
+}
+
+func TestMatchSourceName(t *testing.T) {
+	for i, test := range []struct {
+		name    string
+		pattern string
+		expect  bool
+	}{
+		{"args", "args", true},
+		{"envs", "args", false},
+		{"/etc/app/ops.yaml", "ops.yaml", true},
+		{"./testdata/test1.yaml", "testdata/test1.yaml", true},
+		{"/etc/app/my-ops.yaml", "ops.yaml", false},
+	} {
+		got := matchSourceName(test.name, test.pattern)
+		if got != test.expect {
+			t.Errorf("[%v] name=%v pattern=%v, got(%v)!=expect(%v)\n", i, test.name, test.pattern, got, test.expect)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package olayc
+
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Decode toml bytes.
+// The toml tables are decoded to map[string]any, and arrays of tables are decoded to []map[string]any,
+// they are converted to map[any]any and []any to be compatible with `copyMap()`.
+// Offset datetimes are kept as time.Time, local datetimes/dates/times have no time zone, they are converted to strings.
+func decodeToml(data []byte) (map[any]any, error) {
+	var m = make(map[string]any)
+	err := toml.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	return convertTomlValue(m).(map[any]any), nil
+}
+
+// Convert toml value recursively.
+func convertTomlValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[any]any, len(x))
+		for k, sub := range x {
+			m[k] = convertTomlValue(sub)
+		}
+		return m
+	case []map[string]any:
+		l := make([]any, 0, len(x))
+		for _, sub := range x {
+			l = append(l, convertTomlValue(sub))
+		}
+		return l
+	case []any:
+		l := make([]any, 0, len(x))
+		for _, sub := range x {
+			l = append(l, convertTomlValue(sub))
+		}
+		return l
+	case time.Time:
+		// The local values are decoded with special locations by toml.
+		switch x.Location().String() {
+		case "datetime-local":
+			return x.Format("2006-01-02T15:04:05.999999999")
+		case "date-local":
+			return x.Format("2006-01-02")
+		case "time-local":
+			return x.Format("15:04:05.999999999")
+		}
+	}
+	return v
+}
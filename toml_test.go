@@ -0,0 +1,95 @@
+package olayc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigGetValueLoadToml(t *testing.T) {
+	var testdata = []byte(`
+[foo]
+name = "foo1"
+id = 123
+pi = 3.1415926
+temp = -50
+onoff = true
+created = 1979-05-27T07:32:00Z
+birthday = 1979-05-27
+wakeup = 07:32:00
+alarm = 1979-05-27T07:32:00
+
+[foo.redis]
+host = "redis.cluster"
+port = 6380
+
+[[foo.servers]]
+host = "10.0.0.1"
+
+[[foo.servers]]
+host = "10.0.0.2"
+`)
+
+	var c = New()
+	err := c.LoadToml(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect any
+	}{
+		{"foo-not-exisit", nil},
+		{"foo.name", "foo1"},
+		{"foo.id", int64(123)},
+		{"foo.pi", float64(3.1415926)},
+		{"foo.temp", int64(-50)},
+		{"foo.onoff", true},
+		{"foo.created", time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)},
+		{"foo.birthday", "1979-05-27"},
+		{"foo.wakeup", "07:32:00"},
+		{"foo.alarm", "1979-05-27T07:32:00"},
+		{"foo.redis.host", "redis.cluster"},
+		{"foo.redis.port", int64(6380)},
+		{"foo.servers", []any{
+			map[any]any{"host": "10.0.0.1"},
+			map[any]any{"host": "10.0.0.2"},
+		}},
+	} {
+		got := c.Get(test.key)
+		if !reflect.DeepEqual(got.v, test.expect) {
+			t.Errorf("[%v] key=%v, got(%v)!=expect(%v)\n", i, test.key, got.v, test.expect)
+		}
+	}
+}
+
+func TestConfigGetScalarOverlayWithToml(t *testing.T) {
+	var c = New()
+	err := c.LoadYaml([]byte(`
+foo:
+  name: foo-yaml
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.LoadTomlFile("./testdata/test1.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		key    string
+		expect string
+	}{
+		{"foo.name", "foo-yaml"},
+		{"foo.id", "789"},
+		{"foo.redis.host", "redis.toml"},
+		{"foo.redis.port", "6379"},
+	} {
+		got := c.String(test.key, "")
+		if got != test.expect {
+			t.Errorf("[%v] key=%v, got(\"%v\")!=expect(\"%v\")\n", i, test.key, got, test.expect)
+		}
+	}
+}
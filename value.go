@@ -2,21 +2,47 @@ package olayc
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 )
 
 // Value represents a configure value, it can be scalar node or sub-tree node.
+// The value of a missing key is nil, and the value of a null key is `null`.
 type Value struct {
 	v any
 }
 
-// Return if it's nil value
+// nullValue is the type of `null`.
+type nullValue struct{}
+
+// null is the value of Value if the key is set to null, it's different from the missing key.
+var null = nullValue{}
+
+// Return if it's nil value, which is missing or null.
 func (v *Value) IsNil() bool {
+	return v.v == nil || v.v == null
+}
+
+// Return if the key is missing, it doesn't exist or it's deleted.
+func (v *Value) IsMissing() bool {
 	return v.v == nil
 }
 
+// Return if the key exists with null value.
+func (v *Value) IsNull() bool {
+	return v.v == null
+}
+
+// Return the raw value, it's nil for both missing and null.
+func (v *Value) raw() any {
+	if v.v == null {
+		return nil
+	}
+	return v.v
+}
+
 // Get string value, return "" if it doesn't exist.
 func (v *Value) String() string {
 	if v.v == nil {
@@ -32,6 +58,8 @@ func (v *Value) String() string {
 		float32, float64,
 		bool:
 		s = fmt.Sprintf("%v", x)
+	case time.Time:
+		s = x.Format(time.RFC3339Nano)
 	}
 	return s
 }
@@ -199,7 +227,7 @@ func (v *Value) Bool() bool {
 // then the yaml bytes is unmarshal to target out.
 // Thus, if 'out' is a struct, you must use the yaml struct tag.
 func (v *Value) Unmarshal(out any) error {
-	data, err := yaml.Marshal(v.v)
+	data, err := yaml.Marshal(v.raw())
 	if err != nil {
 		return errors.Wrap(err, "Value.Unmarshal fail")
 	}
@@ -208,5 +236,5 @@ func (v *Value) Unmarshal(out any) error {
 
 // Marshal value to yaml bytes.
 func (v *Value) MarshalToYaml() ([]byte, error) {
-	return yaml.Marshal(v.v)
+	return yaml.Marshal(v.raw())
 }